@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -26,6 +27,17 @@ func ExecuteWorkflowHandler(c *gin.Context) {
 		return
 	}
 
+	// Validate graph and node configs before building, so every problem is
+	// reported in the structured form
+	if problems := engine.Validate(); problems != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid workflow definition",
+			"details": problems.Error(),
+			"errors":  problems,
+		})
+		return
+	}
+
 	// Build nodes
 	if err := engine.BuildNodes(); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -68,6 +80,15 @@ func CreateWorkflowHandler(c *gin.Context) {
 		return
 	}
 
+	if problems := engine.Validate(); problems != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid workflow definition",
+			"details": problems.Error(),
+			"errors":  problems,
+		})
+		return
+	}
+
 	engine.Workflow.ID = uuid.New().String()
 
 	collection := nodes.MongoClient.Database("workflow_db").Collection("workflows")
@@ -82,6 +103,30 @@ func CreateWorkflowHandler(c *gin.Context) {
 	})
 }
 
+func ValidateWorkflowHandler(c *gin.Context) {
+	engine := workflow.NewEngine()
+
+	if err := engine.LoadWorkflowFromPayload(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid workflow definition",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	problems := engine.Validate()
+	if problems == nil {
+		problems = workflow.ValidationErrors{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":         len(problems) == 0,
+		"workflow_id":   engine.Workflow.ID,
+		"workflow_name": engine.Workflow.Name,
+		"errors":        problems,
+	})
+}
+
 func ExecuteWorkflowByIdHandler(c *gin.Context) {
 	workflowId := c.Query("workflow_id")
 	engine := workflow.NewEngine()
@@ -108,6 +153,15 @@ func ExecuteWorkflowByIdHandler(c *gin.Context) {
 		return
 	}
 
+	if problems := engine.Validate(); problems != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid workflow definition",
+			"details": problems.Error(),
+			"errors":  problems,
+		})
+		return
+	}
+
 	if err := engine.BuildNodes(); err != nil {
 
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -123,7 +177,22 @@ func ExecuteWorkflowByIdHandler(c *gin.Context) {
         return
     }
 
-	engine.Execute(inputData)
+	if _, reserved := inputData[workflow.NodesKey]; reserved {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": fmt.Sprintf("input key %q is reserved for node records", workflow.NodesKey),
+		})
+		return
+	}
+
+	if err := engine.Execute(inputData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Workflow execution failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":        "success",
 		"message":       "Workflow executed successfully",
@@ -63,6 +63,8 @@ func main() {
 
 	router.POST("/create-workflow", CreateWorkflowHandler)
 
+	router.POST("/validate-workflow", ValidateWorkflowHandler)
+
 	// Start server
 	log.Println("🚀 Starting workflow engine API on :3002")
 	log.Println("📱 UI available at: http://localhost:3002/ui")
@@ -1,10 +1,30 @@
 package workflow
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
+
+// NodesKey is the context key under which nodes see the recorded outputs of
+// previously executed nodes, e.g. {{nodes.register-user.insertedID}}.
+const NodesKey = "nodes"
 
 type WorkflowContext struct {
-	Data  map[string]interface{}
+	Data map[string]interface{}
+	// Nodes holds the recorded result of every executed node keyed by node ID.
+	// It lives on the root context and on isolated scopes (see Isolate); branch
+	// scopes created by Fork share the one of the scope they were forked from.
+	Nodes map[string]interface{}
 	mutex sync.RWMutex
+
+	// parent is set for branch scopes created by Fork. Reads fall through to
+	// the parent, writes stay local until the branch is merged back.
+	parent  *WorkflowContext
+	deleted map[string]bool
+	// joined marks a branch scope whose writes were handed to a join node
+	joined bool
+	// goCtx is the Go context nodes in this scope run under, see GoContext
+	goCtx context.Context
 }
 
 func NewWorkflowContext(initialData map[string]interface{}) *WorkflowContext {
@@ -13,16 +33,65 @@ func NewWorkflowContext(initialData map[string]interface{}) *WorkflowContext {
 	}
 	return &WorkflowContext{
 		Data:  initialData,
+		Nodes: make(map[string]interface{}),
 		mutex: sync.RWMutex{},
 	}
 }
 
+// Fork returns a copy-on-write scope for a parallel branch. The branch sees
+// everything in ctx, but its own writes are only visible to itself until
+// they are merged back, so concurrent branches cannot overwrite each other.
+func (ctx *WorkflowContext) Fork() *WorkflowContext {
+	return &WorkflowContext{
+		Data:    make(map[string]interface{}),
+		parent:  ctx,
+		deleted: make(map[string]bool),
+	}
+}
+
+// Isolate returns a scope like Fork that also keeps its own node records:
+// nodes executed in it, or in branches forked from it, are recorded on the
+// scope instead of on ctx, so ctx never sees them.
+func (ctx *WorkflowContext) Isolate() *WorkflowContext {
+	scope := ctx.Fork()
+	scope.Nodes = make(map[string]interface{})
+	return scope
+}
+
+// IsolateWithContext is Isolate for a scope whose nodes run under goCtx
+// instead of the context of ctx.
+func (ctx *WorkflowContext) IsolateWithContext(goCtx context.Context) *WorkflowContext {
+	scope := ctx.Isolate()
+	scope.goCtx = goCtx
+	return scope
+}
+
+// GoContext returns the Go context nodes in this scope run under: the one
+// given to the closest IsolateWithContext, or context.Background().
+func (ctx *WorkflowContext) GoContext() context.Context {
+	for scope := ctx; scope != nil; scope = scope.parent {
+		if scope.goCtx != nil {
+			return scope.goCtx
+		}
+	}
+	return context.Background()
+}
+
+// Parent returns the scope this context was forked from, or nil for the root context.
+func (ctx *WorkflowContext) Parent() *WorkflowContext {
+	return ctx.parent
+}
+
 func (ctx *WorkflowContext) Get(key string) (interface{}, bool) {
 	ctx.mutex.RLock()
-	defer ctx.mutex.RUnlock()
-
 	value, ok := ctx.Data[key]
-	return value, ok
+	deleted := ctx.deleted[key]
+	ctx.mutex.RUnlock()
+
+	if ok || deleted || ctx.parent == nil {
+		return value, ok
+	}
+	return ctx.parent.Get(key)
 }
 
 func (ctx *WorkflowContext) Set(key string, value interface{}) {
@@ -30,6 +99,7 @@ func (ctx *WorkflowContext) Set(key string, value interface{}) {
 	defer ctx.mutex.Unlock()
 
 	ctx.Data[key] = value
+	delete(ctx.deleted, key)
 }
 
 func (ctx *WorkflowContext) Delete(key string) {
@@ -37,16 +107,170 @@ func (ctx *WorkflowContext) Delete(key string) {
 	defer ctx.mutex.Unlock()
 
 	delete(ctx.Data, key)
+	if ctx.parent != nil {
+		ctx.deleted[key] = true
+	}
 }
 
 func (ctx *WorkflowContext) GetAll() map[string]interface{} {
+	// Return a copy to prevent external modifications
+	result := make(map[string]interface{})
+	if ctx.parent != nil {
+		result = ctx.parent.GetAll()
+	}
+
 	ctx.mutex.RLock()
 	defer ctx.mutex.RUnlock()
 
-	// Return a copy to prevent external modifications
-	result := make(map[string]interface{})
+	for key := range ctx.deleted {
+		delete(result, key)
+	}
 	for k, v := range ctx.Data {
 		result[k] = v
 	}
 	return result
 }
+
+// SetNodeOutput records the result of a node on the closest scope that keeps
+// node records: the root context or an isolated scope.
+func (ctx *WorkflowContext) SetNodeOutput(nodeId string, output map[string]interface{}) {
+	owner := ctx.recordOwner()
+	owner.mutex.Lock()
+	defer owner.mutex.Unlock()
+
+	owner.Nodes[nodeId] = output
+}
+
+// addNodeOutputs records the node results of a scope that was merged into this one.
+func (ctx *WorkflowContext) addNodeOutputs(records map[string]interface{}) {
+	owner := ctx.recordOwner()
+	owner.mutex.Lock()
+	defer owner.mutex.Unlock()
+
+	for nodeId, record := range records {
+		owner.Nodes[nodeId] = record
+	}
+}
+
+// NodeOutputs returns a copy of the node results visible in this scope keyed
+// by node ID, with records of isolated scopes taking precedence.
+func (ctx *WorkflowContext) NodeOutputs() map[string]interface{} {
+	result := make(map[string]interface{})
+	if ctx.parent != nil {
+		result = ctx.parent.NodeOutputs()
+	}
+
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+
+	for k, v := range ctx.Nodes {
+		result[k] = v
+	}
+	return result
+}
+
+// NodeChanges returns a copy of the node records kept by this scope itself,
+// which is empty unless it is the root context or an isolated scope.
+func (ctx *WorkflowContext) NodeChanges() map[string]interface{} {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+
+	result := make(map[string]interface{}, len(ctx.Nodes))
+	for k, v := range ctx.Nodes {
+		result[k] = v
+	}
+	return result
+}
+
+func (ctx *WorkflowContext) recordOwner() *WorkflowContext {
+	owner := ctx
+	for owner.Nodes == nil && owner.parent != nil {
+		owner = owner.parent
+	}
+	return owner
+}
+
+// Changes returns a copy of the values written in this scope itself and the
+// keys deleted in it.
+func (ctx *WorkflowContext) Changes() (map[string]interface{}, map[string]bool) {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+
+	writes := make(map[string]interface{}, len(ctx.Data))
+	for k, v := range ctx.Data {
+		writes[k] = v
+	}
+	deleted := make(map[string]bool, len(ctx.deleted))
+	for k := range ctx.deleted {
+		deleted[k] = true
+	}
+	return writes, deleted
+}
+
+// changesSince returns the writes and deletions made in this scope and every
+// scope between it and the given ancestor, with changes closer to this scope
+// taking precedence.
+func (ctx *WorkflowContext) changesSince(ancestor *WorkflowContext) (map[string]interface{}, map[string]bool) {
+	if ctx == ancestor || ctx == nil {
+		return make(map[string]interface{}), make(map[string]bool)
+	}
+	writes, deleted := ctx.parent.changesSince(ancestor)
+	scopeWrites, scopeDeleted := ctx.Changes()
+	for k := range scopeDeleted {
+		delete(writes, k)
+		deleted[k] = true
+	}
+	for k, v := range scopeWrites {
+		writes[k] = v
+		delete(deleted, k)
+	}
+	return writes, deleted
+}
+
+// mergeScope applies the writes and deletions made in scope itself to ctx.
+func (ctx *WorkflowContext) mergeScope(scope *WorkflowContext) {
+	writes, deleted := scope.Changes()
+	for key := range deleted {
+		ctx.Delete(key)
+	}
+	for key, value := range writes {
+		ctx.Set(key, value)
+	}
+}
+
+func (ctx *WorkflowContext) markJoined() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.joined = true
+}
+
+func (ctx *WorkflowContext) isJoined() bool {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+
+	return ctx.joined
+}
+
+// commonAncestor returns the closest scope that every given scope was forked from (or is).
+func commonAncestor(scopes []*WorkflowContext) *WorkflowContext {
+	if len(scopes) == 0 {
+		return nil
+	}
+	candidate := scopes[0]
+	for _, scope := range scopes[1:] {
+		for candidate != nil && !scope.descendsFrom(candidate) {
+			candidate = candidate.parent
+		}
+	}
+	return candidate
+}
+
+func (ctx *WorkflowContext) descendsFrom(ancestor *WorkflowContext) bool {
+	for scope := ctx; scope != nil; scope = scope.parent {
+		if scope == ancestor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// findCycles reports every cycle in the graph that is not broken by a loop
+// edge (an edge with MaxIterations > 0). Guarded edges are removed before
+// looking for strongly connected components, so each reported component is
+// a set of nodes that could recurse forever.
+func (e *Engine) findCycles(order []string) ValidationErrors {
+	known := make(map[string]bool, len(order))
+	for _, id := range order {
+		known[id] = true
+	}
+
+	var edges []Edge
+	adjacency := make(map[string][]string)
+	for _, edge := range e.Workflow.Edges {
+		if edge.MaxIterations > 0 || !known[edge.From] || !known[edge.To] {
+			continue
+		}
+		edges = append(edges, edge)
+		adjacency[edge.From] = append(adjacency[edge.From], edge.To)
+	}
+
+	var problems ValidationErrors
+	for _, component := range stronglyConnected(order, adjacency) {
+		members := make(map[string]bool, len(component))
+		for _, id := range component {
+			members[id] = true
+		}
+
+		var cycleEdges []Edge
+		var described []string
+		for _, edge := range edges {
+			if members[edge.From] && members[edge.To] {
+				cycleEdges = append(cycleEdges, edge)
+				described = append(described, fmt.Sprintf("%s -> %s (%s)", edge.From, edge.To, edge.Output))
+			}
+		}
+		if len(component) == 1 && len(cycleEdges) == 0 {
+			continue
+		}
+
+		problems = append(problems, ValidationError{
+			NodeID:  component[0],
+			Edges:   cycleEdges,
+			Code:    "cycle",
+			Message: fmt.Sprintf("cycle without maxIterations guard: %s", strings.Join(described, ", ")),
+		})
+	}
+	return problems
+}
+
+// loopMembers returns the nodes on any cycle, including cycles broken by a
+// loop edge, which may run more than once per execution.
+func (e *Engine) loopMembers(order []string) map[string]bool {
+	known := make(map[string]bool, len(order))
+	for _, id := range order {
+		known[id] = true
+	}
+
+	members := make(map[string]bool)
+	adjacency := make(map[string][]string)
+	for _, edge := range e.Workflow.Edges {
+		if !known[edge.From] || !known[edge.To] {
+			continue
+		}
+		adjacency[edge.From] = append(adjacency[edge.From], edge.To)
+		if edge.From == edge.To {
+			members[edge.From] = true
+		}
+	}
+	for _, component := range stronglyConnected(order, adjacency) {
+		if len(component) > 1 {
+			for _, id := range component {
+				members[id] = true
+			}
+		}
+	}
+	return members
+}
+
+// stronglyConnected returns the strongly connected components of the graph
+// using Tarjan's algorithm. Nodes are visited in the given order and each
+// component lists its nodes in workflow order so results are deterministic.
+func stronglyConnected(order []string, adjacency map[string][]string) [][]string {
+	position := make(map[string]int, len(order))
+	for i, id := range order {
+		position[id] = i
+	}
+
+	index := 0
+	indices := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		indices[id] = index
+		lowlink[id] = index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range adjacency[id] {
+			if _, seen := indices[next]; !seen {
+				visit(next)
+				lowlink[id] = min(lowlink[id], lowlink[next])
+			} else if onStack[next] {
+				lowlink[id] = min(lowlink[id], indices[next])
+			}
+		}
+
+		if lowlink[id] != indices[id] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		sort.Slice(component, func(i, j int) bool {
+			return position[component[i]] < position[component[j]]
+		})
+		components = append(components, component)
+	}
+
+	for _, id := range order {
+		if _, seen := indices[id]; !seen {
+			visit(id)
+		}
+	}
+
+	// Report components in the order their first node appears in the workflow
+	sort.Slice(components, func(i, j int) bool {
+		return position[components[i][0]] < position[components[j][0]]
+	})
+	return components
+}
@@ -0,0 +1,150 @@
+package workflow
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindCycles(t *testing.T) {
+	nodes := []testNode{
+		start(),
+		step("a", emit("default", nil)),
+		step("b", emit("default", nil)),
+		step("c", emit("default", nil)),
+	}
+
+	tests := []struct {
+		name  string
+		edges []Edge
+		// want lists the edges of each reported cycle as from->to
+		want [][]string
+	}{
+		{
+			name: "acyclic",
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "a", To: "b", Output: "default"},
+				{From: "a", To: "c", Output: "default"},
+				{From: "b", To: "c", Output: "default"},
+			},
+		},
+		{
+			name: "self loop",
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "a", To: "a", Output: "default"},
+			},
+			want: [][]string{{"a->a"}},
+		},
+		{
+			name: "self loop with maxIterations",
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "a", To: "a", Output: "default", MaxIterations: 3},
+			},
+		},
+		{
+			name: "three node cycle",
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "a", To: "b", Output: "default"},
+				{From: "b", To: "c", Output: "default"},
+				{From: "c", To: "a", Output: "default"},
+			},
+			want: [][]string{{"a->b", "b->c", "c->a"}},
+		},
+		{
+			name: "three node cycle broken by maxIterations",
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "a", To: "b", Output: "default"},
+				{From: "b", To: "c", Output: "default"},
+				{From: "c", To: "a", Output: "default", MaxIterations: 2},
+			},
+		},
+		{
+			name: "one of two cycles guarded",
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "a", To: "a", Output: "default", MaxIterations: 2},
+				{From: "a", To: "b", Output: "default"},
+				{From: "b", To: "c", Output: "default"},
+				{From: "c", To: "b", Output: "default"},
+			},
+			want: [][]string{{"b->c", "c->b"}},
+		},
+		{
+			name: "guard outside the strongly connected component",
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "a", To: "b", Output: "default", MaxIterations: 2},
+				{From: "b", To: "c", Output: "default"},
+				{From: "c", To: "b", Output: "default"},
+			},
+			want: [][]string{{"b->c", "c->b"}},
+		},
+		{
+			name: "two separate cycles",
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "a", To: "a", Output: "default"},
+				{From: "a", To: "b", Output: "default"},
+				{From: "b", To: "c", Output: "default"},
+				{From: "c", To: "b", Output: "default"},
+			},
+			want: [][]string{{"a->a"}, {"b->c", "c->b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestEngine(nodes, tt.edges...)
+			var got [][]string
+			for _, problem := range engine.findCycles([]string{"start", "a", "b", "c"}) {
+				if problem.Code != "cycle" {
+					t.Errorf("code = %s, want cycle", problem.Code)
+				}
+				var edges []string
+				for _, edge := range problem.Edges {
+					edges = append(edges, edge.From+"->"+edge.To)
+				}
+				got = append(got, edges)
+			}
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("findCycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoopEdgeMaxIterations(t *testing.T) {
+	newEngine := func(runs *int) *Engine {
+		counter := funcNode(func(map[string]interface{}) (NodeResult, error) {
+			*runs++
+			if *runs < 3 {
+				return NodeResult{Output: "again"}, nil
+			}
+			return NodeResult{Output: "done"}, nil
+		})
+		return newTestEngine(
+			[]testNode{start(), step("count", counter)},
+			Edge{From: "start", To: "count", Output: "default"},
+			Edge{From: "count", To: "count", Output: "again", MaxIterations: 2},
+		)
+	}
+
+	var runs int
+	if err := newEngine(&runs).Execute(nil); err != nil {
+		t.Fatalf("Execute() = %v", err)
+	}
+	if runs != 3 {
+		t.Errorf("count ran %d times, want 3", runs)
+	}
+
+	runs = -1
+	err := newEngine(&runs).Execute(nil)
+	if err == nil || !strings.Contains(err.Error(), "exceeded maxIterations 2") {
+		t.Errorf("Execute() = %v, want maxIterations error", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +15,12 @@ type Engine struct {
 	Workflow Workflow
 	Nodes    map[string]Node
 	Context  *WorkflowContext
+
+	// loopCounts tracks how often each loop edge was followed in the current execution
+	loopCounts map[Edge]int
+	// joins holds the branches that have arrived at each join node
+	joins map[string]*joinState
+	mutex sync.Mutex
 }
 
 func NewEngine() *Engine {
@@ -61,14 +68,27 @@ func (e *Engine) BuildNodes() error {
 }
 
 func (e *Engine) Execute(inputData map[string]interface{}) error {
+	if problems := e.Validate(); problems != nil {
+		return problems
+	}
+
+	if _, reserved := inputData[NodesKey]; reserved {
+		return fmt.Errorf("input key %q is reserved for node records", NodesKey)
+	}
+
 	ctx := NewWorkflowContext(inputData)
 	e.Context = ctx
+	e.loopCounts = make(map[Edge]int)
+	e.joins = make(map[string]*joinState)
 	startNode := e.findStartNode()
 	if startNode == nil {
 		return fmt.Errorf("no start node found")
 	}
 	log.Printf("Starting workflow: %s", e.Workflow.Name)
-	return e.executeNode(startNode.ID, ctx)
+	if err := e.executeNode(startNode.ID, Branch{}, ctx); err != nil {
+		return err
+	}
+	return e.pendingJoinsError()
 
 }
 
@@ -81,13 +101,43 @@ func (e *Engine) findStartNode() *NodeDefinition {
 	return nil
 }
 
-func (e *Engine) executeNode(nodeId string, ctx *WorkflowContext) error {
+func (e *Engine) executeNode(nodeId string, arrival Branch, ctx *WorkflowContext) error {
 	node, exist := e.Nodes[nodeId]
 	if !exist {
 		return fmt.Errorf("node not found")
 	}
 
-	response, err := node.Execute(ctx.GetAll())
+	var response NodeResult
+	var err error
+	if joiner, ok := node.(Joiner); ok {
+		arrival.scope = ctx
+		branches, ready := e.arriveAtJoin(nodeId, joiner, arrival)
+		if !ready {
+			log.Printf("Branch from %s arrived at join %s, waiting for others", arrival.From, nodeId)
+			return nil
+		}
+		// Continue in the scope the branches were forked from
+		ctx = joinBranches(branches, len(e.incomingSources(nodeId)))
+		started := time.Now()
+		response, err = checkReserved(joiner.Join(nodeInput(ctx), branches))
+		ctx.SetNodeOutput(nodeId, nodeRecord(response, time.Since(started), err))
+	} else if streamer, ok := node.(Streamer); ok {
+		started := time.Now()
+		response, err = checkReserved(e.runStream(nodeId, streamer, ctx))
+		ctx.SetNodeOutput(nodeId, nodeRecord(response, time.Since(started), err))
+	} else if scope, ok := node.(Scope); ok {
+		started := time.Now()
+		response, err = checkReserved(e.runScope(nodeId, scope, ctx))
+		ctx.SetNodeOutput(nodeId, nodeRecord(response, time.Since(started), err))
+	} else if contextNode, ok := node.(ContextNode); ok {
+		started := time.Now()
+		response, err = checkReserved(contextNode.ExecuteContext(ctx.GoContext(), nodeInput(ctx)))
+		ctx.SetNodeOutput(nodeId, nodeRecord(response, time.Since(started), err))
+	} else {
+		started := time.Now()
+		response, err = checkReserved(node.Execute(nodeInput(ctx)))
+		ctx.SetNodeOutput(nodeId, nodeRecord(response, time.Since(started), err))
+	}
 	if err != nil {
 		return fmt.Errorf("error executing node %s: %w", nodeId, err)
 	}
@@ -98,9 +148,16 @@ func (e *Engine) executeNode(nodeId string, ctx *WorkflowContext) error {
 	for key, value := range response.Data {
 		ctx.Set(key, value)
 	}
+	for _, key := range response.Deleted {
+		ctx.Delete(key)
+	}
 
 	// log.Printf("Context after node %s: %v", nodeId, ctx.GetAll())
 
+	if err := e.countLoopIterations(nodeId, response.Output); err != nil {
+		return err
+	}
+
 	nextNodes := e.findNextNodes(nodeId, response.Output)
 	log.Printf("Next nodes: %v", nextNodes)
 	log.Printf("Response: %v", response.Output)
@@ -110,16 +167,59 @@ func (e *Engine) executeNode(nodeId string, ctx *WorkflowContext) error {
 		return nil
 	}
 
+	next := Branch{From: nodeId, Data: response.Data}
 	if len(nextNodes) == 1 {
-		return e.executeNode(nextNodes[0], ctx)
+		return e.executeNode(nextNodes[0], next, ctx)
 	}
 
 	// Multiple paths - execute in parallel
 	log.Printf("Executing %d nodes in parallel", len(nextNodes))
-	return e.executeNodeParallel(nextNodes, ctx)
+	return e.executeNodeParallel(nextNodes, next, ctx)
 
 }
 
+// nodeInput builds the context map handed to a node: the visible context
+// data plus the recorded outputs of every node executed so far under "nodes".
+func nodeInput(ctx *WorkflowContext) map[string]interface{} {
+	input := ctx.GetAll()
+	input[NodesKey] = ctx.NodeOutputs()
+	return input
+}
+
+// checkReserved fails a result that writes NodesKey. Nodes would never see
+// the value, since nodeInput replaces it with the node records.
+func checkReserved(response NodeResult, err error) (NodeResult, error) {
+	if _, reserved := response.Data[NodesKey]; reserved && err == nil {
+		return response, fmt.Errorf("result key %q is reserved for node records", NodesKey)
+	}
+	return response, err
+}
+
+// reservedRecordKeys are the keys of a node record that describe the run
+// rather than hold result data.
+var reservedRecordKeys = map[string]bool{"output": true, "data": true, "durationMs": true, "error": true}
+
+// nodeRecord builds the entry stored under nodes.<nodeId>: the node's result
+// data plus its output label, raw data, duration and error. Result keys that
+// clash with those are not copied to the top level, so they are only
+// available under data, e.g. {{nodes.<nodeId>.data.error}}.
+func nodeRecord(response NodeResult, duration time.Duration, err error) map[string]interface{} {
+	record := make(map[string]interface{}, len(response.Data)+4)
+	for key, value := range response.Data {
+		if !reservedRecordKeys[key] {
+			record[key] = value
+		}
+	}
+	record["output"] = response.Output
+	record["data"] = response.Data
+	record["durationMs"] = duration.Milliseconds()
+	record["error"] = nil
+	if err != nil {
+		record["error"] = err.Error()
+	}
+	return record
+}
+
 func (e *Engine) findNextNodes(fromNode string, output string) []string {
 	var nextNodes []string
 
@@ -131,26 +231,55 @@ func (e *Engine) findNextNodes(fromNode string, output string) []string {
 	return nextNodes
 }
 
-func (e *Engine) executeNodeParallel(nodeIds []string, ctx *WorkflowContext) error {
+// countLoopIterations records one traversal of every loop edge leaving the
+// node on the given output and fails once an edge exceeds its MaxIterations.
+func (e *Engine) countLoopIterations(fromNode string, output string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, edge := range e.Workflow.Edges {
+		if edge.From != fromNode || edge.Output != output || edge.MaxIterations <= 0 {
+			continue
+		}
+		e.loopCounts[edge]++
+		if e.loopCounts[edge] > edge.MaxIterations {
+			return fmt.Errorf("loop edge %s -> %s (%s) exceeded maxIterations %d", edge.From, edge.To, edge.Output, edge.MaxIterations)
+		}
+	}
+	return nil
+}
+
+func (e *Engine) executeNodeParallel(nodeIds []string, arrival Branch, ctx *WorkflowContext) error {
 	var wg sync.WaitGroup
 	errChan := make(chan error, len(nodeIds))
-	for _, nodeId := range nodeIds {
+	scopes := make([]*WorkflowContext, len(nodeIds))
+	for i, nodeId := range nodeIds {
+		// Each branch writes to its own scope so branches cannot clobber each other
+		scopes[i] = ctx.Fork()
 		wg.Add(1)
-		go func(id string) {
+		go func(id string, scope *WorkflowContext) {
 			defer wg.Done()
 			log.Printf("Started executing id: %s ", id)
-			err := e.executeNode(id, ctx)
+			err := e.executeNode(id, arrival, scope)
 			if err != nil {
 				log.Printf("Execution error for the node: %s, %v", id, err)
 				errChan <- err
 			}
-		}(nodeId)
+		}(nodeId, scopes[i])
 
 	}
 
 	wg.Wait()
 	close(errChan)
 
+	// Branches that ended without reaching a join are merged back in edge order
+	for _, scope := range scopes {
+		if scope.isJoined() {
+			continue
+		}
+		ctx.mergeScope(scope)
+	}
+
 	// Check if any errors occurred
 	for err := range errChan {
 		if err != nil {
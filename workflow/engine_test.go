@@ -0,0 +1,57 @@
+package workflow
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNodeRecords(t *testing.T) {
+	var seen interface{}
+	reader := funcNode(func(ctx map[string]interface{}) (NodeResult, error) {
+		seen = ctx[NodesKey]
+		return NodeResult{Output: "default"}, nil
+	})
+	engine := newTestEngine(
+		[]testNode{start(), step("insert", emit("default", map[string]interface{}{"insertedID": "652f", "error": "kept under data"})), step("read", reader)},
+		Edge{From: "start", To: "insert", Output: "default"},
+		Edge{From: "insert", To: "read", Output: "default"},
+	)
+	if err := engine.Execute(nil); err != nil {
+		t.Fatalf("Execute() = %v", err)
+	}
+
+	records, _ := seen.(map[string]interface{})
+	record, _ := records["insert"].(map[string]interface{})
+	want := map[string]interface{}{
+		"insertedID": "652f",
+		"output":     "default",
+		"data":       map[string]interface{}{"insertedID": "652f", "error": "kept under data"},
+		"error":      nil,
+	}
+	delete(record, "durationMs")
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("nodes.insert = %v, want %v", record, want)
+	}
+}
+
+func TestReservedNodesKey(t *testing.T) {
+	engine := newTestEngine([]testNode{start()})
+	err := engine.Execute(map[string]interface{}{NodesKey: "mine"})
+	if err == nil || !strings.Contains(err.Error(), `input key "nodes" is reserved`) {
+		t.Errorf("Execute() with a nodes input = %v, want reserved key error", err)
+	}
+
+	engine = newTestEngine(
+		[]testNode{start(), step("find", emit("default", map[string]interface{}{NodesKey: []interface{}{}}))},
+		Edge{From: "start", To: "find", Output: "default"},
+	)
+	err = engine.Execute(nil)
+	if err == nil || !strings.Contains(err.Error(), `error executing node find: result key "nodes" is reserved`) {
+		t.Errorf("Execute() with a nodes result = %v, want reserved key error", err)
+	}
+	record, _ := engine.Context.NodeOutputs()["find"].(map[string]interface{})
+	if record["error"] == nil {
+		t.Errorf("nodes.find.error = nil, want the reserved key error")
+	}
+}
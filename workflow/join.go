@@ -0,0 +1,219 @@
+package workflow
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type joinState struct {
+	branches []Branch
+	fired    bool
+}
+
+// arriveAtJoin records a branch arriving at a join node. It returns the
+// collected branches and true exactly once, for the arrival that makes the
+// join ready; every other arrival gets false and ends its branch there.
+// Branches are returned in the order of the join's incoming edges so the
+// join sees them deterministically regardless of which finished first.
+func (e *Engine) arriveAtJoin(nodeId string, joiner Joiner, arrival Branch) ([]Branch, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	state, ok := e.joins[nodeId]
+	if !ok {
+		state = &joinState{}
+		e.joins[nodeId] = state
+	}
+	arrival.scope.markJoined()
+	if state.fired {
+		log.Printf("Join %s already fired, ignoring branch from %s", nodeId, arrival.From)
+		return nil, false
+	}
+
+	state.branches = append(state.branches, arrival)
+	sources := e.incomingSources(nodeId)
+	if !joiner.Ready(len(state.branches), len(sources)) {
+		return nil, false
+	}
+	state.fired = true
+
+	position := make(map[string]int, len(sources))
+	for i, from := range sources {
+		position[from] = i
+	}
+	branches := append([]Branch(nil), state.branches...)
+	sort.SliceStable(branches, func(i, j int) bool {
+		return position[branches[i].From] < position[branches[j].From]
+	})
+	return branches, true
+}
+
+// joinBranches finds the scope the arrived branches were forked from and
+// replaces each forked branch's data and deletions with everything it wrote
+// and deleted since then, marking every scope in between as joined.
+// The returned scope is where execution continues after the join.
+func joinBranches(branches []Branch, expected int) *WorkflowContext {
+	scopes := make([]*WorkflowContext, len(branches))
+	for i, branch := range branches {
+		scopes[i] = branch.scope
+	}
+
+	target := commonAncestor(scopes)
+	if len(branches) == 1 && expected > 1 && target.Parent() != nil {
+		// A single branch fired the join early ("any" mode); leave its fork
+		target = target.Parent()
+	}
+	for i, branch := range branches {
+		if branch.scope != target {
+			branches[i].Data, branches[i].Deleted = branch.scope.changesSince(target)
+		}
+		// The scopes between the branch and target were folded in above, so
+		// they must not be merged into target again when their forks finish
+		for scope := branch.scope; scope != nil && scope != target; scope = scope.Parent() {
+			scope.markJoined()
+		}
+	}
+	return target
+}
+
+// incomingSources returns the distinct nodes with an edge into the given node, in edge order.
+func (e *Engine) incomingSources(nodeId string) []string {
+	var sources []string
+	seen := make(map[string]bool)
+	for _, edge := range e.Workflow.Edges {
+		if edge.To == nodeId && !seen[edge.From] {
+			seen[edge.From] = true
+			sources = append(sources, edge.From)
+		}
+	}
+	return sources
+}
+
+// pendingJoinsError reports joins that received branches but never became
+// ready. The branches that arrived were dropped there, so the nodes after
+// the join never ran.
+func (e *Engine) pendingJoinsError() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var pending []string
+	for _, nodeDef := range e.Workflow.Nodes {
+		state, ok := e.joins[nodeDef.ID]
+		if !ok || state.fired {
+			continue
+		}
+		pending = append(pending, fmt.Sprintf("%s (%d of %d branches arrived)", nodeDef.ID, len(state.branches), len(e.incomingSources(nodeDef.ID))))
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	return fmt.Errorf("join never fired: %s", strings.Join(pending, ", "))
+}
+
+// findJoinsInLoops reports joins on a cycle, guarded or not. A join fires
+// once per execution, so every branch arriving on a later pass of the loop
+// would be dropped.
+func (e *Engine) findJoinsInLoops(order []string, nodes map[string]Node) ValidationErrors {
+	loops := e.loopMembers(order)
+	var problems ValidationErrors
+	for _, id := range order {
+		if _, ok := nodes[id].(Joiner); ok && loops[id] {
+			problems = append(problems, ValidationError{
+				NodeID:  id,
+				Code:    "join_in_loop",
+				Message: "join is inside a loop but fires only once per execution",
+			})
+		}
+	}
+	return problems
+}
+
+// findExclusiveJoins reports joins that wait for every incoming branch while
+// two of the branches can never both run: each is only reachable through a
+// different output of the same node, and a node that runs once emits one
+// output. A condition's true and false outputs feeding one join are the
+// usual case.
+func (e *Engine) findExclusiveJoins(startNode string, order []string, nodes map[string]Node) ValidationErrors {
+	reachable := e.reachableFrom(startNode)
+	loops := e.loopMembers(order)
+	once := make(map[string]bool)
+	var problems ValidationErrors
+	for _, id := range order {
+		joiner, ok := nodes[id].(Joiner)
+		if !ok {
+			continue
+		}
+		sources := e.incomingSources(id)
+		if len(sources) < 2 || joiner.Ready(len(sources)-1, len(sources)) {
+			continue
+		}
+
+	decisions:
+		for _, decider := range order {
+			if _, ok := subGraphOutput(nodes[decider]); ok || !e.runsOnce(decider, nodes, loops, once) {
+				continue
+			}
+			// The sources that only run when decider emits each of its outputs
+			required := make(map[string]string)
+			var outputs []string
+			for _, output := range e.edgeOutputs(decider) {
+				isOutput := func(edge Edge) bool {
+					return edge.From == decider && edge.Output == output
+				}
+				without := e.reachableVia([]string{startNode}, isOutput)
+				for _, source := range sources {
+					if reachable[source] && !without[source] {
+						required[output] = source
+						outputs = append(outputs, output)
+						break
+					}
+				}
+				if len(outputs) == 2 {
+					problems = append(problems, ValidationError{
+						NodeID:  id,
+						Code:    "join_exclusive_branches",
+						Message: fmt.Sprintf("join waits for every branch, but %s and %s never both run: %s emits either %q or %q", required[outputs[0]], required[outputs[1]], decider, outputs[0], outputs[1]),
+					})
+					break decisions
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// runsOnce reports whether a node runs at most once per execution: it is not
+// on a cycle and it is either a join or only entered from a single node that
+// runs once itself. Results are cached in once.
+func (e *Engine) runsOnce(nodeId string, nodes map[string]Node, loops map[string]bool, once map[string]bool) bool {
+	if result, ok := once[nodeId]; ok {
+		return result
+	}
+	result := false
+	if !loops[nodeId] {
+		sources := e.incomingSources(nodeId)
+		_, isJoin := nodes[nodeId].(Joiner)
+		switch {
+		case isJoin, len(sources) == 0:
+			result = true
+		case len(sources) == 1:
+			result = e.runsOnce(sources[0], nodes, loops, once)
+		}
+	}
+	once[nodeId] = result
+	return result
+}
+
+// edgeOutputs returns the distinct outputs a node has edges on, in edge order.
+func (e *Engine) edgeOutputs(nodeId string) []string {
+	var outputs []string
+	for _, edge := range e.Workflow.Edges {
+		if edge.From == nodeId && !slices.Contains(outputs, edge.Output) {
+			outputs = append(outputs, edge.Output)
+		}
+	}
+	return outputs
+}
@@ -0,0 +1,63 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJoinFiresOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		join stubJoin
+	}{
+		{"all", stubJoin{}},
+		{"any", stubJoin{count: 1}},
+		{"two of three", stubJoin{count: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs int
+			after := funcNode(func(map[string]interface{}) (NodeResult, error) {
+				runs++
+				return NodeResult{Output: "default"}, nil
+			})
+			engine := newTestEngine(
+				[]testNode{start(), step("a", emit("default", nil)), step("b", emit("default", nil)), step("c", emit("default", nil)), step("join", tt.join), step("after", after)},
+				Edge{From: "start", To: "a", Output: "default"},
+				Edge{From: "start", To: "b", Output: "default"},
+				Edge{From: "start", To: "c", Output: "default"},
+				Edge{From: "a", To: "join", Output: "default"},
+				Edge{From: "b", To: "join", Output: "default"},
+				Edge{From: "c", To: "join", Output: "default"},
+				Edge{From: "join", To: "after", Output: "default"},
+			)
+			if err := engine.Execute(nil); err != nil {
+				t.Fatalf("Execute() = %v", err)
+			}
+			if runs != 1 {
+				t.Errorf("node after the join ran %d times, want 1", runs)
+			}
+		})
+	}
+}
+
+// TestJoinNeverFired checks that a run fails when a join got some of its
+// branches but not enough to fire, here because one branch took a route
+// that does not lead to it.
+func TestJoinNeverFired(t *testing.T) {
+	condition := declaringNode{emit("false", nil), []string{"true", "false"}}
+	engine := newTestEngine(
+		[]testNode{start(), step("a", emit("default", nil)), step("check", condition), step("join", stubJoin{}), step("other", emit("default", nil))},
+		Edge{From: "start", To: "a", Output: "default"},
+		Edge{From: "start", To: "check", Output: "default"},
+		Edge{From: "a", To: "join", Output: "default"},
+		Edge{From: "check", To: "join", Output: "true"},
+		Edge{From: "check", To: "other", Output: "false"},
+	)
+
+	err := engine.Execute(nil)
+	if err == nil || !strings.Contains(err.Error(), "join never fired: join (1 of 2 branches arrived)") {
+		t.Errorf("Execute() = %v, want join never fired", err)
+	}
+}
@@ -0,0 +1,98 @@
+package workflow
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// MergeStrategy decides how the writes of parallel branches are combined
+// when they meet at a join node. Branches are always merged in the order of
+// the join's incoming edges, so every strategy is deterministic.
+type MergeStrategy string
+
+const (
+	// MergeLastWriterWins applies branches in edge order; later branches overwrite earlier ones.
+	MergeLastWriterWins MergeStrategy = "last-writer-wins"
+	// MergeErrorOnConflict fails when two branches wrote different values to the same key.
+	MergeErrorOnConflict MergeStrategy = "error-on-conflict"
+	// MergeNamespaced merges nothing into the shared context; each branch's
+	// writes are only available under the join's per-branch output key.
+	MergeNamespaced MergeStrategy = "namespaced"
+	// MergeArrayAppend turns every written key into an array holding each
+	// writing branch's value in edge order.
+	MergeArrayAppend MergeStrategy = "array-append"
+)
+
+// ParseMergeStrategy validates a merge strategy name from a node config.
+func ParseMergeStrategy(name string) (MergeStrategy, error) {
+	switch strategy := MergeStrategy(name); strategy {
+	case MergeLastWriterWins, MergeErrorOnConflict, MergeNamespaced, MergeArrayAppend:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown merge strategy: %s", name)
+	}
+}
+
+// MergeBranches combines the data of the given branches into the values that
+// should be written to the context the branches joined back into, and the
+// keys that should be deleted from it. A branch's deletions count as writes
+// that remove the key: a later branch's write or deletion wins over them
+// with last-writer-wins, and they conflict with another branch's write with
+// error-on-conflict. With array-append a key is only deleted when no branch
+// wrote it.
+func MergeBranches(branches []Branch, strategy MergeStrategy) (map[string]interface{}, []string, error) {
+	merged := make(map[string]interface{})
+	deleted := make(map[string]bool)
+	writers := make(map[string]string)
+	deleters := make(map[string]string)
+
+	for _, branch := range branches {
+		if strategy == MergeNamespaced {
+			// Available through the join's per-branch output only
+			continue
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(branch.Deleted)) {
+			switch strategy {
+			case MergeArrayAppend:
+				if _, written := merged[key]; !written {
+					deleted[key] = true
+				}
+			case MergeErrorOnConflict:
+				if writer, ok := writers[key]; ok {
+					return nil, nil, fmt.Errorf("merge conflict on key %s: written by branch %s and deleted by branch %s", key, writer, branch.From)
+				}
+				deleted[key] = true
+				deleters[key] = branch.From
+			default:
+				delete(merged, key)
+				deleted[key] = true
+			}
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(branch.Data)) {
+			value := branch.Data[key]
+			switch strategy {
+			case MergeArrayAppend:
+				values, _ := merged[key].([]interface{})
+				merged[key] = append(values, value)
+				delete(deleted, key)
+			case MergeErrorOnConflict:
+				if deleter, ok := deleters[key]; ok {
+					return nil, nil, fmt.Errorf("merge conflict on key %s: deleted by branch %s and written by branch %s", key, deleter, branch.From)
+				}
+				if existing, ok := merged[key]; ok && !reflect.DeepEqual(existing, value) {
+					return nil, nil, fmt.Errorf("merge conflict on key %s: written by branches %s and %s", key, writers[key], branch.From)
+				}
+				merged[key] = value
+				writers[key] = branch.From
+			default:
+				merged[key] = value
+				delete(deleted, key)
+			}
+		}
+	}
+	return merged, slices.Sorted(maps.Keys(deleted)), nil
+}
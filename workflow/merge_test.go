@@ -0,0 +1,448 @@
+package workflow
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMergeBranches(t *testing.T) {
+	branches := []Branch{
+		{From: "a", Data: map[string]interface{}{"total": 1.0, "a": true}},
+		{From: "b", Data: map[string]interface{}{"total": 2.0, "b": true}},
+	}
+
+	tests := []struct {
+		strategy MergeStrategy
+		want     map[string]interface{}
+		err      string
+	}{
+		{MergeLastWriterWins, map[string]interface{}{"total": 2.0, "a": true, "b": true}, ""},
+		{MergeErrorOnConflict, nil, "merge conflict on key total: written by branches a and b"},
+		{MergeNamespaced, map[string]interface{}{}, ""},
+		{MergeArrayAppend, map[string]interface{}{
+			"total": []interface{}{1.0, 2.0},
+			"a":     []interface{}{true},
+			"b":     []interface{}{true},
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			got, _, err := MergeBranches(branches, tt.strategy)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("MergeBranches() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeBranches() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeBranches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeErrorOnConflictAllowsEqualValues(t *testing.T) {
+	branches := []Branch{
+		{From: "a", Data: map[string]interface{}{"tags": []interface{}{"x"}}},
+		{From: "b", Data: map[string]interface{}{"tags": []interface{}{"x"}}},
+	}
+	got, _, err := MergeBranches(branches, MergeErrorOnConflict)
+	if err != nil || !reflect.DeepEqual(got, branches[0].Data) {
+		t.Errorf("MergeBranches() = %v, %v", got, err)
+	}
+}
+
+func TestMergeBranchesDeletions(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy MergeStrategy
+		branches []Branch
+		want     map[string]interface{}
+		deleted  []string
+		err      string
+	}{
+		{
+			name:     "deletion without writes",
+			strategy: MergeLastWriterWins,
+			branches: []Branch{
+				{From: "a", Deleted: map[string]bool{"user": true}},
+				{From: "b", Data: map[string]interface{}{"b": true}},
+			},
+			want:    map[string]interface{}{"b": true},
+			deleted: []string{"user"},
+		},
+		{
+			name:     "later write wins over deletion",
+			strategy: MergeLastWriterWins,
+			branches: []Branch{
+				{From: "a", Deleted: map[string]bool{"total": true}},
+				{From: "b", Data: map[string]interface{}{"total": 2.0}},
+			},
+			want: map[string]interface{}{"total": 2.0},
+		},
+		{
+			name:     "later deletion wins over write",
+			strategy: MergeLastWriterWins,
+			branches: []Branch{
+				{From: "a", Data: map[string]interface{}{"total": 1.0}},
+				{From: "b", Deleted: map[string]bool{"total": true}},
+			},
+			want:    map[string]interface{}{},
+			deleted: []string{"total"},
+		},
+		{
+			name:     "write and deletion conflict",
+			strategy: MergeErrorOnConflict,
+			branches: []Branch{
+				{From: "a", Data: map[string]interface{}{"total": 1.0}},
+				{From: "b", Deleted: map[string]bool{"total": true}},
+			},
+			err: "merge conflict on key total: written by branch a and deleted by branch b",
+		},
+		{
+			name:     "deletion and write conflict",
+			strategy: MergeErrorOnConflict,
+			branches: []Branch{
+				{From: "a", Deleted: map[string]bool{"total": true}},
+				{From: "b", Data: map[string]interface{}{"total": 2.0}},
+			},
+			err: "merge conflict on key total: deleted by branch a and written by branch b",
+		},
+		{
+			name:     "deletions do not conflict with each other",
+			strategy: MergeErrorOnConflict,
+			branches: []Branch{
+				{From: "a", Deleted: map[string]bool{"total": true}},
+				{From: "b", Deleted: map[string]bool{"total": true}},
+			},
+			want:    map[string]interface{}{},
+			deleted: []string{"total"},
+		},
+		{
+			name:     "array-append keeps written keys",
+			strategy: MergeArrayAppend,
+			branches: []Branch{
+				{From: "a", Deleted: map[string]bool{"total": true, "user": true}},
+				{From: "b", Data: map[string]interface{}{"total": 2.0}},
+			},
+			want:    map[string]interface{}{"total": []interface{}{2.0}},
+			deleted: []string{"user"},
+		},
+		{
+			name:     "namespaced deletes nothing",
+			strategy: MergeNamespaced,
+			branches: []Branch{
+				{From: "a", Deleted: map[string]bool{"user": true}},
+			},
+			want: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, deleted, err := MergeBranches(tt.branches, tt.strategy)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("MergeBranches() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeBranches() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(deleted, tt.deleted) {
+				t.Errorf("MergeBranches() = %v, deleted %v, want %v, deleted %v", got, deleted, tt.want, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestParseMergeStrategy(t *testing.T) {
+	for _, name := range []string{"last-writer-wins", "error-on-conflict", "namespaced", "array-append"} {
+		if strategy, err := ParseMergeStrategy(name); err != nil || string(strategy) != name {
+			t.Errorf("ParseMergeStrategy(%q) = %q, %v", name, strategy, err)
+		}
+	}
+	if _, err := ParseMergeStrategy("first-writer-wins"); err == nil {
+		t.Error("ParseMergeStrategy(\"first-writer-wins\") should fail")
+	}
+}
+
+// parallelWrites returns the nodes and edges of a workflow where a and b run
+// in parallel, writing 1 and 2 to total, and then meet at join.
+func parallelWrites(join Node) ([]testNode, []Edge) {
+	nodes := []testNode{
+		start(),
+		step("a", emit("default", map[string]interface{}{"total": 1.0})),
+		step("b", emit("default", map[string]interface{}{"total": 2.0})),
+		step("join", join),
+	}
+	edges := []Edge{
+		{From: "start", To: "a", Output: "default"},
+		{From: "start", To: "b", Output: "default"},
+		{From: "a", To: "join", Output: "default"},
+		{From: "b", To: "join", Output: "default"},
+	}
+	return nodes, edges
+}
+
+func TestJoinMergesBranchScopes(t *testing.T) {
+	tests := []struct {
+		strategy MergeStrategy
+		want     interface{}
+		exists   bool
+	}{
+		{MergeLastWriterWins, 2.0, true},
+		{MergeArrayAppend, []interface{}{1.0, 2.0}, true},
+		{MergeNamespaced, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			nodes, edges := parallelWrites(stubJoin{merge: tt.strategy})
+			engine := newTestEngine(nodes, edges...)
+			if err := engine.Execute(map[string]interface{}{"user": "ada"}); err != nil {
+				t.Fatalf("Execute() = %v", err)
+			}
+			got, exists := engine.Context.Get("total")
+			if exists != tt.exists || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("total = %v (exists %v), want %v (exists %v)", got, exists, tt.want, tt.exists)
+			}
+			if user, _ := engine.Context.Get("user"); user != "ada" {
+				t.Errorf("user = %v, want ada", user)
+			}
+		})
+	}
+
+	nodes, edges := parallelWrites(stubJoin{merge: MergeErrorOnConflict})
+	err := newTestEngine(nodes, edges...).Execute(nil)
+	if err == nil || !strings.Contains(err.Error(), "merge conflict on key total") {
+		t.Errorf("Execute() with error-on-conflict = %v, want merge conflict", err)
+	}
+}
+
+func TestBranchesDoNotSeeEachOther(t *testing.T) {
+	var mutex sync.Mutex
+	seen := make(map[string]interface{})
+	reader := func(id string, data map[string]interface{}) funcNode {
+		return func(ctx map[string]interface{}) (NodeResult, error) {
+			mutex.Lock()
+			for key, value := range ctx {
+				if key != NodesKey {
+					seen[id+"."+key] = value
+				}
+			}
+			mutex.Unlock()
+			return NodeResult{Output: "default", Data: data}, nil
+		}
+	}
+
+	engine := newTestEngine(
+		[]testNode{
+			start(),
+			step("a", emit("default", map[string]interface{}{"fromA": true})),
+			step("b", emit("default", map[string]interface{}{"fromB": true})),
+			step("afterA", reader("afterA", nil)),
+			step("afterB", reader("afterB", nil)),
+		},
+		Edge{From: "start", To: "a", Output: "default"},
+		Edge{From: "start", To: "b", Output: "default"},
+		Edge{From: "a", To: "afterA", Output: "default"},
+		Edge{From: "b", To: "afterB", Output: "default"},
+	)
+	if err := engine.Execute(map[string]interface{}{"shared": 1.0}); err != nil {
+		t.Fatalf("Execute() = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"afterA.shared": 1.0, "afterA.fromA": true,
+		"afterB.shared": 1.0, "afterB.fromB": true,
+	}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("branches saw %v, want %v", seen, want)
+	}
+	// Branches that end without a join are merged back once all of them finished
+	for _, key := range []string{"shared", "fromA", "fromB"} {
+		if _, ok := engine.Context.Get(key); !ok {
+			t.Errorf("%s missing from the context after the run", key)
+		}
+	}
+}
+
+// TestBranchDeletionsSurviveMerge checks that a key deleted in a branch stays
+// deleted once the branch is merged back, at a join or at the end of the run.
+func TestBranchDeletionsSurviveMerge(t *testing.T) {
+	deleteUser := funcNode(func(map[string]interface{}) (NodeResult, error) {
+		return NodeResult{Output: "default", Deleted: []string{"user"}}, nil
+	})
+	tests := []struct {
+		name  string
+		nodes []testNode
+		edges []Edge
+	}{
+		{
+			name: "join",
+			nodes: []testNode{
+				start(),
+				step("a", deleteUser),
+				step("b", emit("default", map[string]interface{}{"b": true})),
+				step("join", stubJoin{merge: MergeLastWriterWins}),
+			},
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "start", To: "b", Output: "default"},
+				{From: "a", To: "join", Output: "default"},
+				{From: "b", To: "join", Output: "default"},
+			},
+		},
+		{
+			name: "unjoined",
+			nodes: []testNode{
+				start(),
+				step("a", deleteUser),
+				step("b", emit("default", map[string]interface{}{"b": true})),
+			},
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "start", To: "b", Output: "default"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestEngine(tt.nodes, tt.edges...)
+			if err := engine.Execute(map[string]interface{}{"user": "ada"}); err != nil {
+				t.Fatalf("Execute() = %v", err)
+			}
+			if user, ok := engine.Context.Get("user"); ok {
+				t.Errorf("user = %v after the merge, want it deleted", user)
+			}
+			if b, _ := engine.Context.Get("b"); b != true {
+				t.Errorf("b = %v, want true", b)
+			}
+		})
+	}
+}
+
+// TestNestedForksJoinOnce checks that a branch forked again before reaching
+// a join is not merged back a second time once its forks finish, which would
+// overwrite what the nodes after the join wrote.
+func TestNestedForksJoinOnce(t *testing.T) {
+	engine := newTestEngine(
+		[]testNode{
+			start(),
+			step("p", emit("default", map[string]interface{}{"x": 1.0})),
+			step("p1", emit("default", nil)),
+			step("p2", emit("default", nil)),
+			step("q", emit("default", nil)),
+			step("join", stubJoin{merge: MergeLastWriterWins}),
+			step("z", emit("default", map[string]interface{}{"x": 2.0})),
+		},
+		Edge{From: "start", To: "p", Output: "default"},
+		Edge{From: "start", To: "q", Output: "default"},
+		Edge{From: "p", To: "p1", Output: "default"},
+		Edge{From: "p", To: "p2", Output: "default"},
+		Edge{From: "p1", To: "join", Output: "default"},
+		Edge{From: "p2", To: "join", Output: "default"},
+		Edge{From: "q", To: "join", Output: "default"},
+		Edge{From: "join", To: "z", Output: "default"},
+	)
+	if err := engine.Execute(nil); err != nil {
+		t.Fatalf("Execute() = %v", err)
+	}
+	if x, _ := engine.Context.Get("x"); x != 2.0 {
+		t.Errorf("x = %v, want 2 written after the join", x)
+	}
+}
+
+func TestUnjoinedBranchesMergeInEdgeOrder(t *testing.T) {
+	nodes := []testNode{
+		start(),
+		step("slow", emit("default", map[string]interface{}{"winner": "slow"})),
+		step("fast", emit("default", map[string]interface{}{"winner": "fast"})),
+	}
+	for i := 0; i < 20; i++ {
+		engine := newTestEngine(nodes,
+			Edge{From: "start", To: "slow", Output: "default"},
+			Edge{From: "start", To: "fast", Output: "default"},
+		)
+		if err := engine.Execute(nil); err != nil {
+			t.Fatalf("Execute() = %v", err)
+		}
+		if winner, _ := engine.Context.Get("winner"); winner != "fast" {
+			t.Fatalf("winner = %v, want fast, the later edge", winner)
+		}
+	}
+}
+
+// TestNestedJoins checks that a join inside a branch merges into that
+// branch's scope, and the outer join sees the inner join's result as part of
+// the branch it closes.
+func TestNestedJoins(t *testing.T) {
+	var branches []Branch
+	outer := funcJoin(func(ctx map[string]interface{}, arrived []Branch) (NodeResult, error) {
+		branches = arrived
+		merged, deleted, err := MergeBranches(arrived, MergeLastWriterWins)
+		return NodeResult{Output: "default", Data: merged, Deleted: deleted}, err
+	})
+
+	engine := newTestEngine(
+		[]testNode{
+			start(),
+			step("a", emit("default", map[string]interface{}{"a": true})),
+			step("a1", emit("default", map[string]interface{}{"items": "a1"})),
+			step("a2", emit("default", map[string]interface{}{"items": "a2"})),
+			step("inner", stubJoin{merge: MergeArrayAppend}),
+			step("b", emit("default", map[string]interface{}{"b": true})),
+			step("outer", outer),
+		},
+		Edge{From: "start", To: "a", Output: "default"},
+		Edge{From: "start", To: "b", Output: "default"},
+		Edge{From: "a", To: "a1", Output: "default"},
+		Edge{From: "a", To: "a2", Output: "default"},
+		Edge{From: "a1", To: "inner", Output: "default"},
+		Edge{From: "a2", To: "inner", Output: "default"},
+		Edge{From: "inner", To: "outer", Output: "default"},
+		Edge{From: "b", To: "outer", Output: "default"},
+	)
+	if err := engine.Execute(nil); err != nil {
+		t.Fatalf("Execute() = %v", err)
+	}
+
+	if len(branches) != 2 || branches[0].From != "inner" || branches[1].From != "b" {
+		t.Fatalf("outer join got %v, want branches from inner and b", branches)
+	}
+	wantInner := map[string]interface{}{"a": true, "items": []interface{}{"a1", "a2"}}
+	if !reflect.DeepEqual(branches[0].Data, wantInner) {
+		t.Errorf("branch from inner = %v, want %v", branches[0].Data, wantInner)
+	}
+	if !reflect.DeepEqual(branches[1].Data, map[string]interface{}{"b": true}) {
+		t.Errorf("branch from b = %v", branches[1].Data)
+	}
+	if items, _ := engine.Context.Get("items"); !reflect.DeepEqual(items, []interface{}{"a1", "a2"}) {
+		t.Errorf("items = %v after the outer join", items)
+	}
+}
+
+// funcJoin is a Joiner that fires once every branch arrived and runs the
+// given function.
+type funcJoin func(ctx map[string]interface{}, branches []Branch) (NodeResult, error)
+
+func (f funcJoin) Ready(arrived, expected int) bool {
+	return arrived >= expected
+}
+
+func (f funcJoin) Join(ctx map[string]interface{}, branches []Branch) (NodeResult, error) {
+	return f(ctx, branches)
+}
+
+func (f funcJoin) Execute(ctx map[string]interface{}) (NodeResult, error) {
+	return f(ctx, nil)
+}
@@ -0,0 +1,97 @@
+package nodes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CoercionPolicy controls how comparisons treat operands of different types.
+//
+// Under both policies every numeric type (float64, int, int64 and the other
+// widths, json.Number and BSON Decimal128) compares as a number, and BSON
+// null and undefined compare as null.
+//
+// Loose additionally converts a string when the other operand is a number
+// ("18" == 18) or a boolean ("true" == true), and parses date strings when
+// comparing with a date or another date string. Strict never converts
+// strings: mismatched types are unequal, and ordering them is an error.
+//
+// Booleans never equal numbers under either policy. See "Type Coercion" in
+// the README for the full table.
+type CoercionPolicy string
+
+const (
+	CoercionLoose  CoercionPolicy = "loose"
+	CoercionStrict CoercionPolicy = "strict"
+)
+
+func ParseCoercionPolicy(value string) (CoercionPolicy, error) {
+	switch policy := CoercionPolicy(value); policy {
+	case CoercionLoose, CoercionStrict:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown coercion policy: %s (use loose or strict)", value)
+	}
+}
+
+// coercionFromConfig reads the "coercion" config key, defaulting to loose.
+func coercionFromConfig(config map[string]interface{}) (CoercionPolicy, error) {
+	value, exists := config["coercion"]
+	if !exists {
+		return CoercionLoose, nil
+	}
+	policy, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("coercion must be a string")
+	}
+	return ParseCoercionPolicy(policy)
+}
+
+// normalizeValue maps every numeric type to float64 and BSON null values to nil.
+func normalizeValue(value interface{}) interface{} {
+	switch value.(type) {
+	case primitive.Null, primitive.Undefined:
+		return nil
+	}
+	if number, ok := toFloat64(value); ok {
+		return number
+	}
+	return value
+}
+
+// coerceOperands normalizes both operands and, under the loose policy,
+// converts a string to the type of the other operand when it parses as one.
+func coerceOperands(lhs interface{}, rhs interface{}, policy CoercionPolicy) (interface{}, interface{}) {
+	lhs, rhs = normalizeValue(lhs), normalizeValue(rhs)
+	if policy == CoercionStrict {
+		return lhs, rhs
+	}
+	return coerceString(lhs, rhs), coerceString(rhs, lhs)
+}
+
+// coerceString converts value to the type of other if value is a string
+// holding a number or boolean and other is of that type.
+func coerceString(value interface{}, other interface{}) interface{} {
+	str, ok := value.(string)
+	if !ok {
+		return value
+	}
+
+	switch other.(type) {
+	case float64:
+		if number, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err == nil {
+			return number
+		}
+	case bool:
+		switch strings.ToLower(strings.TrimSpace(str)) {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	return value
+}
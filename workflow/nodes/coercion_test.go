@@ -0,0 +1,201 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// comparisonResult is what a comparison is expected to give: "true",
+// "false" or "error".
+type comparisonResult string
+
+func compareResult(lhs interface{}, rhs interface{}, operator string, coercion CoercionPolicy) comparisonResult {
+	result, err := compareValues(lhs, rhs, operator, coercion)
+	if err != nil {
+		return "error"
+	}
+	return comparisonResult(fmt.Sprint(result))
+}
+
+func mustDecimal(t *testing.T, value string) primitive.Decimal128 {
+	t.Helper()
+	decimal, err := primitive.ParseDecimal128(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decimal
+}
+
+// TestCoercionTruthTable covers every row of the truth table in the "Type
+// Coercion" section of the README under both policies.
+func TestCoercionTruthTable(t *testing.T) {
+	date := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	decimal := mustDecimal(t, "18")
+
+	tests := []struct {
+		name     string
+		lhs      interface{}
+		operator string
+		rhs      interface{}
+		loose    comparisonResult
+		strict   comparisonResult
+	}{
+		{"float64 == int", 18.0, "==", 18, "true", "true"},
+		{"float64 == int32", 18.0, "==", int32(18), "true", "true"},
+		{"float64 == int64", 18.0, "==", int64(18), "true", "true"},
+		{"int32 == int64", int32(18), "==", int64(18), "true", "true"},
+		{"float64 == json.Number", 18.0, "==", json.Number("18"), "true", "true"},
+		{"float64 == Decimal128", 18.0, "==", decimal, "true", "true"},
+		{"int64 == Decimal128", int64(18), "==", decimal, "true", "true"},
+		{"json.Number == Decimal128", json.Number("18.0"), "==", decimal, "true", "true"},
+		{"int64 != int64", int64(18), "!=", int64(19), "true", "true"},
+		{"float64 == numeric string", 18.0, "==", "18", "true", "false"},
+		{"numeric string == int64", "18", "==", int64(18), "true", "false"},
+		{"numeric string with spaces == number", " 18 ", "==", 18.0, "true", "false"},
+		{"numeric string != number", 18.0, "!=", "18", "false", "true"},
+		{"two numeric strings", "18", "==", "18.0", "false", "false"},
+		{"bool == string", true, "==", "true", "true", "false"},
+		{"bool == upper case string", true, "==", "TRUE", "true", "false"},
+		{"bool == number", true, "==", 1.0, "false", "false"},
+		{"bool == int64", false, "==", int64(0), "false", "false"},
+		{"null == BSON null", nil, "==", primitive.Null{}, "true", "true"},
+		{"null == BSON undefined", nil, "==", primitive.Undefined{}, "true", "true"},
+		{"null == empty string", nil, "==", "", "false", "false"},
+		{"null == zero", nil, "==", 0.0, "false", "false"},
+		{"BSON null == zero", primitive.Null{}, "==", int64(0), "false", "false"},
+		{"text == zero", "abc", "==", 0.0, "false", "false"},
+		{"number in mixed array", 18.0, "in", []interface{}{"18", 20.0}, "true", "false"},
+		{"int64 in float array", int64(20), "in", []interface{}{18.0, 20.0}, "true", "true"},
+		{"date == date string", date, "==", "2025-01-01T00:00:00Z", "true", "false"},
+		{"date string == date string", "2025-01-01", "==", "2025-01-01T00:00:00Z", "true", "false"},
+		{"number > numeric string", 20.0, ">", "18", "true", "error"},
+		{"int64 > Decimal128", int64(20), ">", decimal, "true", "true"},
+		{"json.Number < float64", json.Number("17.5"), "<", 18.0, "true", "true"},
+		{"two strings order lexically", "9", ">", "10", "true", "true"},
+		{"bool > bool", true, ">", false, "error", "error"},
+		{"null > number", nil, ">", 1.0, "false", "false"},
+		{"BSON null > number", primitive.Null{}, ">", 1.0, "false", "false"},
+		{"number >= BSON null", 1.0, ">=", primitive.Null{}, "false", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compareResult(tt.lhs, tt.rhs, tt.operator, CoercionLoose); got != tt.loose {
+				t.Errorf("loose: %v %s %v = %s, want %s", tt.lhs, tt.operator, tt.rhs, got, tt.loose)
+			}
+			if got := compareResult(tt.lhs, tt.rhs, tt.operator, CoercionStrict); got != tt.strict {
+				t.Errorf("strict: %v %s %v = %s, want %s", tt.lhs, tt.operator, tt.rhs, got, tt.strict)
+			}
+		})
+	}
+}
+
+func TestNotEqualNegatesEqual(t *testing.T) {
+	values := []interface{}{nil, primitive.Null{}, 18.0, int64(18), json.Number("18"), mustDecimal(t, "18"), "18", "abc", true, ""}
+	for _, policy := range []CoercionPolicy{CoercionLoose, CoercionStrict} {
+		for _, lhs := range values {
+			for _, rhs := range values {
+				equal := compareResult(lhs, rhs, "==", policy)
+				notEqual := compareResult(lhs, rhs, "!=", policy)
+				if (equal == "true") != (notEqual == "false") {
+					t.Errorf("%s: %#v == %#v is %s but != is %s", policy, lhs, rhs, equal, notEqual)
+				}
+			}
+		}
+	}
+}
+
+// TestConditionTextOperand checks that text that is not a single variable is
+// read as a number under loose and kept as a string under strict.
+func TestConditionTextOperand(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		ctx      map[string]interface{}
+		loose    interface{}
+		strict   interface{}
+	}{
+		{"literal number", "18", nil, 18.0, "18"},
+		{"literal text", "abc", nil, "abc", "abc"},
+		{"interpolated number", "{{a}}{{b}}", map[string]interface{}{"a": 1.0, "b": 8.0}, 18.0, "18"},
+		{"variable holding a numeric string", "{{age}}", map[string]interface{}{"age": "18"}, "18", "18"},
+		{"variable holding an int64", "{{age}}", map[string]interface{}{"age": int64(18)}, int64(18), int64(18)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for policy, want := range map[CoercionPolicy]interface{}{CoercionLoose: tt.loose, CoercionStrict: tt.strict} {
+				got, err := Resolver{}.resolveValue(tt.template, tt.ctx, policy)
+				if err != nil {
+					t.Fatalf("%s: %v", policy, err)
+				}
+				if got != want {
+					t.Errorf("%s: resolveValue(%q) = %#v, want %#v", policy, tt.template, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestNullOperators checks that BSON null and undefined are absent and empty
+// like a JSON null, under both policies.
+func TestNullOperators(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		operator string
+		want     comparisonResult
+	}{
+		{"null exists", nil, "exists", "false"},
+		{"BSON null exists", primitive.Null{}, "exists", "false"},
+		{"BSON undefined exists", primitive.Undefined{}, "exists", "false"},
+		{"zero exists", 0.0, "exists", "true"},
+		{"BSON null notExists", primitive.Null{}, "notExists", "true"},
+		{"empty string notExists", "", "notExists", "false"},
+		{"null isEmpty", nil, "isEmpty", "true"},
+		{"BSON null isEmpty", primitive.Null{}, "isEmpty", "true"},
+		{"BSON undefined isNotEmpty", primitive.Undefined{}, "isNotEmpty", "false"},
+		{"empty array isEmpty", []interface{}{}, "isEmpty", "true"},
+		{"zero isEmpty", int64(0), "isEmpty", "false"},
+		{"BSON null contains", primitive.Null{}, "contains", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, policy := range []CoercionPolicy{CoercionLoose, CoercionStrict} {
+				if got := compareResult(tt.value, "x", tt.operator, policy); got != tt.want {
+					t.Errorf("%s: %#v %s = %s, want %s", policy, tt.value, tt.operator, got, tt.want)
+				}
+			}
+		})
+	}
+
+	ctx := map[string]interface{}{"deletedAt": primitive.Null{}}
+	for source, want := range map[string]bool{"exists(deletedAt)": false, "isEmpty(deletedAt)": true} {
+		expression, err := ParseExpression(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := expression.EvaluateBool(ctx); err != nil || got != want {
+			t.Errorf("%s with BSON null = %v, %v, want %v", source, got, err, want)
+		}
+	}
+}
+
+func TestParseCoercionPolicy(t *testing.T) {
+	for _, value := range []string{"loose", "strict"} {
+		if policy, err := ParseCoercionPolicy(value); err != nil || string(policy) != value {
+			t.Errorf("ParseCoercionPolicy(%q) = %q, %v", value, policy, err)
+		}
+	}
+	if _, err := ParseCoercionPolicy("fuzzy"); err == nil {
+		t.Error("ParseCoercionPolicy(\"fuzzy\") should fail")
+	}
+	if policy, err := coercionFromConfig(map[string]interface{}{}); err != nil || policy != CoercionLoose {
+		t.Errorf("default policy = %q, %v, want loose", policy, err)
+	}
+}
@@ -1,7 +1,9 @@
 package nodes
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"github.com/arjun/go-workflow-engine/workflow"
@@ -10,11 +12,62 @@ import (
 type ConditionNode struct {
 	ID       string
 	LHS      string
-	RHS      string // may need to change to interface
+	RHS      interface{} // string template, literal or array of them
 	Operator string
+	// Pattern is the compiled rhs of a matches condition whose pattern has no
+	// placeholders; templated patterns are compiled on every evaluation
+	Pattern *regexp.Regexp
+	// Resolver holds the parsed call placeholders of LHS and RHS
+	Resolver Resolver
+	// Expression replaces LHS/Operator/RHS when the "expression" config is set
+	Expression *Expression
+	Coercion   CoercionPolicy
+	// OnMissing decides what a missing variable does: "error" (default),
+	// "null" (treat it as null), "false" or "route" (emit the "missing" output)
+	OnMissing string
 }
 
 func NewConditionNode(def workflow.NodeDefinition) (*ConditionNode, error) {
+	coercion, err := coercionFromConfig(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	onMissing := "error"
+	if onMissingValue, exists := def.Config["onMissing"]; exists {
+		onMissingStr, ok := onMissingValue.(string)
+		if !ok {
+			return nil, fmt.Errorf("onMissing must be a string")
+		}
+		onMissing = onMissingStr
+	}
+	switch onMissing {
+	case "error", "null", "false", "route":
+	default:
+		return nil, fmt.Errorf("unknown onMissing policy: %s (use error, null, false or route)", onMissing)
+	}
+
+	if expression, exists := def.Config["expression"]; exists {
+		expressionStr, ok := expression.(string)
+		if !ok {
+			return nil, fmt.Errorf("expression must be a string")
+		}
+		parsed, err := ParseExpression(expressionStr)
+		if err != nil {
+			return nil, err
+		}
+		parsed = parsed.WithCoercion(coercion)
+		if onMissing == "null" {
+			parsed = parsed.WithMissingAsNull()
+		}
+		return &ConditionNode{
+			ID:         def.ID,
+			Expression: parsed,
+			Coercion:   coercion,
+			OnMissing:  onMissing,
+		}, nil
+	}
+
 	operator, ok := def.Config["operator"]
 	if !ok {
 		return nil, fmt.Errorf("operator is required")
@@ -25,11 +78,6 @@ func NewConditionNode(def workflow.NodeDefinition) (*ConditionNode, error) {
 		return nil, fmt.Errorf("lhs is required")
 	}
 
-	rhs, ok := def.Config["rhs"]
-	if !ok {
-		return nil, fmt.Errorf("rhs is required")
-	}
-
 	lhsStr, ok := lhs.(string)
 	if !ok {
 		return nil, fmt.Errorf("lhs must be a string")
@@ -39,117 +87,181 @@ func NewConditionNode(def workflow.NodeDefinition) (*ConditionNode, error) {
 	if !ok {
 		return nil, fmt.Errorf("operator must be a string")
 	}
+	if !isKnownOperator(operatorStr) {
+		return nil, fmt.Errorf("unknown operator: %s", operatorStr)
+	}
 
-	rhsStr, ok := rhs.(string)
-	if !ok {
-		return nil, fmt.Errorf("rhs must be a string")
+	// Unary operators such as exists and isEmpty do not take an rhs
+	rhs, ok := def.Config["rhs"]
+	if !ok && !unaryOperators[operatorStr] {
+		return nil, fmt.Errorf("rhs is required")
+	}
+
+	switch rhs.(type) {
+	case nil, string, float64, bool, []interface{}:
+	default:
+		return nil, fmt.Errorf("rhs must be a string, number, boolean or array")
+	}
+
+	// A template rhs can resolve to anything and is only checked when it runs
+	switch operatorStr {
+	case "contains", "startsWith", "endsWith":
+		if _, ok := rhs.(string); !ok {
+			return nil, fmt.Errorf("operator %s requires a string rhs, got %s", operatorStr, describeType(rhs))
+		}
+	}
+
+	if duration, ok := rhs.(string); ok && (operatorStr == "olderThan" || operatorStr == "newerThan") {
+		if _, isTemplate := templateVariable(duration); !isTemplate {
+			if _, err := parseDuration(duration); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var pattern *regexp.Regexp
+	if patternStr, ok := rhs.(string); ok && operatorStr == "matches" && !strings.Contains(patternStr, "{{") {
+		if pattern, err = compilePattern(patternStr); err != nil {
+			return nil, err
+		}
+	}
+
+	var resolver Resolver
+	if err := resolver.Prepare([]interface{}{lhsStr, rhs}); err != nil {
+		return nil, err
 	}
 
 	return &ConditionNode{
-		ID:       def.ID,
-		LHS:      lhsStr,
-		RHS:      rhsStr,
-		Operator: operatorStr,
+		ID:        def.ID,
+		LHS:       lhsStr,
+		RHS:       rhs,
+		Operator:  operatorStr,
+		Pattern:   pattern,
+		Resolver:  resolver,
+		Coercion:  coercion,
+		OnMissing: onMissing,
 	}, nil
 
 }
 
 func (n *ConditionNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
-	var output string = "false"
-
-	lhsValue, err := resolveValue(n.LHS, ctx)
+	output, err := n.check(ctx)
 	if err != nil {
 		return workflow.NodeResult{}, err
 	}
 
-	rhsValue, err := resolveValue(n.RHS, ctx)
-	if err != nil {
-		return workflow.NodeResult{}, err
-	}
+	return workflow.NodeResult{
+		Output: output,
+	}, nil
+}
 
-	result, err := compareValues(lhsValue, rhsValue, n.Operator)
+// check evaluates the condition and returns the output to follow, applying
+// the onMissing policy to missing variables.
+func (n *ConditionNode) check(ctx map[string]interface{}) (string, error) {
+	result, err := n.evaluate(ctx)
 	if err != nil {
-		return workflow.NodeResult{}, err
+		var missing *MissingVariableError
+		if !errors.As(err, &missing) {
+			return "", err
+		}
+		switch n.OnMissing {
+		case "false":
+			return "false", nil
+		case "route":
+			return "missing", nil
+		default:
+			return "", err
+		}
 	}
 
 	if result {
-		output = "true"
+		return "true", nil
 	}
-	return workflow.NodeResult{
-		Output: output,
-		Data:   ctx,
-	}, nil
+	return "false", nil
 }
 
-func compareValues(lhs interface{}, rhs interface{}, operator string) (bool, error) {
-	lhsFloat, lhsIsNum := toFloat64(lhs)
-	rhsFloat, rhsIsNum := toFloat64(rhs)
-
-	// If both are numbers, do numeric comparison
-	if lhsIsNum && rhsIsNum {
-		switch operator {
-		case "==":
-			return lhsFloat == rhsFloat, nil
-		case "!=":
-			return lhsFloat != rhsFloat, nil
-		case ">":
-			return lhsFloat > rhsFloat, nil
-		case "<":
-			return lhsFloat < rhsFloat, nil
-		case ">=":
-			return lhsFloat >= rhsFloat, nil
-		case "<=":
-			return lhsFloat <= rhsFloat, nil
-		default:
-			return false, fmt.Errorf("unknown operator: %s", operator)
+func (n *ConditionNode) evaluate(ctx map[string]interface{}) (bool, error) {
+	if n.Expression != nil {
+		return n.Expression.EvaluateBool(ctx)
+	}
+
+	lhsValue, err := n.resolveOperand(n.LHS, ctx)
+	if err != nil {
+		var missing *MissingVariableError
+		if errors.As(err, &missing) && (n.Operator == "exists" || n.Operator == "notExists") {
+			return n.Operator == "notExists", nil
 		}
+		return false, err
 	}
 
-	// For non-numeric values, only == and != make sense
-	switch operator {
-	case "==":
-		return lhs == rhs, nil
-	case "!=":
-		return lhs != rhs, nil
-	default:
-		return false, fmt.Errorf("operator %s not supported for non-numeric values", operator)
+	if n.Pattern != nil {
+		return compareValues(lhsValue, n.Pattern, n.Operator, n.Coercion)
 	}
 
+	rhsValue, err := n.resolveOperand(n.RHS, ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return compareValues(lhsValue, rhsValue, n.Operator, n.Coercion)
 }
 
-func toFloat64(v interface{}) (float64, bool) {
-	switch val := v.(type) {
-	case float64:
-		return val, true
-	case float32:
-		return float64(val), true
-	case int:
-		return float64(val), true
-	case int32:
-		return float64(val), true
-	case int64:
-		return float64(val), true
+// resolveOperand resolves an operand that may be a template string, a literal or an array of them.
+func (n *ConditionNode) resolveOperand(operand interface{}, ctx map[string]interface{}) (interface{}, error) {
+	switch val := operand.(type) {
+	case string:
+		value, err := n.Resolver.resolveValue(val, ctx, n.Coercion)
+		var missing *MissingVariableError
+		if n.OnMissing == "null" && errors.As(err, &missing) {
+			return nil, nil
+		}
+		return value, err
+	case []interface{}:
+		resolved := make([]interface{}, len(val))
+		for i, item := range val {
+			value, err := n.resolveOperand(item, ctx)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = value
+		}
+		return resolved, nil
 	default:
-		return 0, false
+		return operand, nil
 	}
 }
 
-func resolveValue(template string, ctx map[string]interface{}) (interface{}, error) {
-	if strings.HasPrefix(template, "{{") && strings.HasSuffix(template, "}}") {
-		varName := strings.TrimPrefix(template, "{{")
-		varName = strings.TrimSuffix(varName, "}}")
-		varName = strings.TrimSpace(varName)
+// resolveValue resolves a template operand. Under the loose coercion policy
+// text that is not a single variable, such as "18" or "{{a}}{{b}}", is read
+// as a number when it parses as one; strict keeps it as a string.
+func (r Resolver) resolveValue(template string, ctx map[string]interface{}, coercion CoercionPolicy) (interface{}, error) {
+	resolved, err := r.ResolveString(template, ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		value, exists := ctx[varName]
-		if !exists {
-			return nil, fmt.Errorf("variable %s not found in context", varName)
-		}
-		return value, nil
+	text, ok := resolved.(string)
+	if !ok {
+		return resolved, nil
+	}
+	if _, isTemplate := templateVariable(template); isTemplate && !strings.Contains(template[2:], "{{") {
+		// The whole value was a variable holding a string, keep it as a string
+		return text, nil
+	}
+	if coercion == CoercionStrict {
+		return text, nil
 	}
 
-	if numValue, err := strconv.ParseFloat(template, 64); err == nil {
+	if numValue, err := strconv.ParseFloat(text, 64); err == nil {
 		return numValue, nil
 	}
 
-	return template, nil
+	return text, nil
+}
+
+func (n *ConditionNode) Outputs() []string {
+	if n.OnMissing == "route" {
+		return []string{"true", "false", "missing"}
+	}
+	return []string{"true", "false"}
 }
@@ -0,0 +1,281 @@
+package nodes
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+func newCondition(config map[string]interface{}) (*ConditionNode, error) {
+	return NewConditionNode(workflow.NodeDefinition{ID: "check", Type: "condition", Config: config})
+}
+
+// TestConditionOnMissing checks the output each onMissing policy picks when
+// a variable is missing, for both the lhs/rhs form and expressions. An empty
+// want means the node must fail with a MissingVariableError.
+func TestConditionOnMissing(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		ctx    map[string]interface{}
+		want   map[string]string // output per onMissing policy
+	}{
+		{
+			name:   "lhs/rhs comparison",
+			config: map[string]interface{}{"lhs": "{{referralCode}}", "operator": "startsWith", "rhs": "VIP-"},
+			ctx:    map[string]interface{}{},
+			want:   map[string]string{"error": "", "null": "false", "false": "false", "route": "missing"},
+		},
+		{
+			name:   "lhs/rhs equal to null",
+			config: map[string]interface{}{"lhs": "{{referralCode}}", "operator": "==", "rhs": nil},
+			ctx:    map[string]interface{}{},
+			want:   map[string]string{"error": "", "null": "true", "false": "false", "route": "missing"},
+		},
+		{
+			name:   "missing rhs",
+			config: map[string]interface{}{"lhs": "{{age}}", "operator": ">=", "rhs": "{{minAge}}"},
+			ctx:    map[string]interface{}{"age": 30.0},
+			want:   map[string]string{"error": "", "null": "false", "false": "false", "route": "missing"},
+		},
+		{
+			name:   "exists never misses",
+			config: map[string]interface{}{"lhs": "{{referralCode}}", "operator": "exists"},
+			ctx:    map[string]interface{}{},
+			want:   map[string]string{"error": "false", "null": "false", "false": "false", "route": "false"},
+		},
+		{
+			name:   "present variable",
+			config: map[string]interface{}{"lhs": "{{referralCode}}", "operator": "startsWith", "rhs": "VIP-"},
+			ctx:    map[string]interface{}{"referralCode": "VIP-7"},
+			want:   map[string]string{"error": "true", "null": "true", "false": "true", "route": "true"},
+		},
+		{
+			name:   "expression comparison",
+			config: map[string]interface{}{"expression": "age >= 18"},
+			ctx:    map[string]interface{}{},
+			want:   map[string]string{"error": "", "null": "false", "false": "false", "route": "missing"},
+		},
+		{
+			name:   "expression equal to null",
+			config: map[string]interface{}{"expression": "referralCode == null"},
+			ctx:    map[string]interface{}{},
+			want:   map[string]string{"error": "", "null": "true", "false": "false", "route": "missing"},
+		},
+		{
+			name:   "expression negated",
+			config: map[string]interface{}{"expression": "!(user.age >= 18)"},
+			ctx:    map[string]interface{}{"user": map[string]interface{}{}},
+			want:   map[string]string{"error": "", "null": "true", "false": "false", "route": "missing"},
+		},
+		{
+			name:   "expression short-circuits past the missing variable",
+			config: map[string]interface{}{"expression": "vip || age >= 18"},
+			ctx:    map[string]interface{}{"vip": true},
+			want:   map[string]string{"error": "true", "null": "true", "false": "true", "route": "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, policy := range []string{"error", "null", "false", "route"} {
+			t.Run(tt.name+"/"+policy, func(t *testing.T) {
+				config := map[string]interface{}{"onMissing": policy}
+				for key, value := range tt.config {
+					config[key] = value
+				}
+				node, err := newCondition(config)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				result, err := node.Execute(tt.ctx)
+				want := tt.want[policy]
+				if want == "" {
+					var missing *MissingVariableError
+					if !errors.As(err, &missing) {
+						t.Fatalf("Execute() = %q, %v, want a missing variable error", result.Output, err)
+					}
+					return
+				}
+				if err != nil || result.Output != want {
+					t.Errorf("Execute() = %q, %v, want %q", result.Output, err, want)
+				}
+			})
+		}
+	}
+}
+
+func TestConditionOnMissingOutputs(t *testing.T) {
+	tests := map[string][]string{
+		"error": {"true", "false"},
+		"null":  {"true", "false"},
+		"false": {"true", "false"},
+		"route": {"true", "false", "missing"},
+	}
+	for policy, want := range tests {
+		node, err := newCondition(map[string]interface{}{"expression": "age >= 18", "onMissing": policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := node.Outputs(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Outputs() = %v, want %v", policy, got, want)
+		}
+	}
+
+	if _, err := newCondition(map[string]interface{}{"expression": "age >= 18", "onMissing": "skip"}); err == nil {
+		t.Error("NewConditionNode() with onMissing skip should fail")
+	}
+}
+
+// TestConditionNullCoalescing checks that ?? supplies a fallback for a
+// missing or null variable, so the condition needs no onMissing policy.
+func TestConditionNullCoalescing(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		ctx        map[string]interface{}
+		want       string
+	}{
+		{"missing uses the fallback", "(discount ?? 0) > 10", map[string]interface{}{}, "false"},
+		{"null uses the fallback", "(discount ?? 20) > 10", map[string]interface{}{"discount": nil}, "true"},
+		{"present value wins", "(discount ?? 0) > 10", map[string]interface{}{"discount": 15.0}, "true"},
+		{"false is not null", "(vip ?? true) == false", map[string]interface{}{"vip": false}, "true"},
+		{"missing path uses the fallback", `(user.tier ?? "basic") == "basic"`, map[string]interface{}{"user": map[string]interface{}{}}, "true"},
+		{"chained fallbacks", "(discount ?? coupon ?? 5) == 5", map[string]interface{}{}, "true"},
+		{"fallback from another variable", "(discount ?? defaultDiscount) > 10", map[string]interface{}{"defaultDiscount": 12.0}, "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := newCondition(map[string]interface{}{"expression": tt.expression})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := node.Execute(tt.ctx)
+			if err != nil || result.Output != tt.want {
+				t.Errorf("Execute() = %q, %v, want %q", result.Output, err, tt.want)
+			}
+		})
+	}
+
+	// A missing fallback is still missing
+	node, err := newCondition(map[string]interface{}{"expression": "(discount ?? defaultDiscount) > 10", "onMissing": "route"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := node.Execute(map[string]interface{}{}); err != nil || result.Output != "missing" {
+		t.Errorf("Execute() with both sides missing = %q, %v, want missing", result.Output, err)
+	}
+}
+
+func TestConditionOperators(t *testing.T) {
+	ctx := map[string]interface{}{
+		"country": "FRA",
+		"home":    "FRA",
+		"age":     30.0,
+		"name":    "bob",
+		"tags":    []interface{}{"admin", "beta"},
+		"scores":  []interface{}{70.0, 100.0},
+		"top":     100.0,
+		"profile": map[string]interface{}{"a": 1.0, "b": 2.0},
+		"limits":  []interface{}{18.0, 65.0},
+	}
+
+	tests := []struct {
+		name     string
+		lhs      string
+		operator string
+		rhs      interface{}
+		want     string
+		err      string // substring of the Execute error
+	}{
+		{name: "in", lhs: "{{country}}", operator: "in", rhs: []interface{}{"USA", "FRA"}, want: "true"},
+		{name: "not in", lhs: "{{country}}", operator: "in", rhs: []interface{}{"USA", "CAN"}, want: "false"},
+		{name: "in with a templated element", lhs: "{{country}}", operator: "in", rhs: []interface{}{"USA", "{{home}}"}, want: "true"},
+		{name: "notIn", lhs: "{{country}}", operator: "notIn", rhs: []interface{}{"USA", "CAN"}, want: "true"},
+		{name: "in a templated array", lhs: "beta", operator: "in", rhs: "{{tags}}", want: "true"},
+		{name: "in a non-array", lhs: "{{country}}", operator: "in", rhs: "{{name}}", err: "operator in requires an array rhs, got string"},
+		{name: "between", lhs: "{{age}}", operator: "between", rhs: []interface{}{18.0, 65.0}, want: "true"},
+		{name: "between is inclusive", lhs: "{{age}}", operator: "between", rhs: []interface{}{30.0, 30.0}, want: "true"},
+		{name: "outside between", lhs: "{{age}}", operator: "between", rhs: []interface{}{40.0, 65.0}, want: "false"},
+		{name: "between templated bounds", lhs: "{{age}}", operator: "between", rhs: "{{limits}}", want: "true"},
+		{name: "between strings", lhs: "{{name}}", operator: "between", rhs: []interface{}{"alice", "carol"}, want: "true"},
+		{name: "between three bounds", lhs: "{{age}}", operator: "between", rhs: []interface{}{1.0, 2.0, 3.0}, err: "operator between requires an array rhs of [min, max]"},
+		{name: "between a number and strings", lhs: "{{age}}", operator: "between", rhs: []interface{}{"a", "z"}, err: "operator between requires two numbers, dates or strings, got float64 and string"},
+		{name: "string order", lhs: "{{name}}", operator: ">", rhs: "alice", want: "true"},
+		{name: "upper case sorts first", lhs: "Zed", operator: "<", rhs: "alice", want: "true"},
+		{name: "strings order lexically", lhs: "{{name}}", operator: "<=", rhs: "bob", want: "true"},
+		{name: "order a list", lhs: "{{tags}}", operator: ">", rhs: 1.0, err: "operator > requires two numbers, dates or strings, got []interface {} and float64"},
+		{name: "contains substring", lhs: "{{name}}", operator: "contains", rhs: "ob", want: "true"},
+		{name: "contains element", lhs: "{{tags}}", operator: "contains", rhs: "admin", want: "true"},
+		{name: "contains a templated number", lhs: "{{scores}}", operator: "contains", rhs: "{{top}}", want: "true"},
+		{name: "contains on a number", lhs: "{{age}}", operator: "contains", rhs: "3", err: "operator contains requires a string or array lhs, got float64"},
+		{name: "startsWith", lhs: "{{name}}", operator: "startsWith", rhs: "bo", want: "true"},
+		{name: "endsWith", lhs: "{{name}}", operator: "endsWith", rhs: "bo", want: "false"},
+		{name: "startsWith on a list", lhs: "{{tags}}", operator: "startsWith", rhs: "ad", err: "operator startsWith requires string operands, got []interface {} and string"},
+		{name: "startsWith a templated number", lhs: "{{name}}", operator: "startsWith", rhs: "{{age}}", err: "operator startsWith requires string operands, got string and float64"},
+		{name: "lengthEq on an array", lhs: "{{tags}}", operator: "lengthEq", rhs: 2.0, want: "true"},
+		{name: "lengthGt on a string", lhs: "{{name}}", operator: "lengthGt", rhs: 3.0, want: "false"},
+		{name: "lengthGte on a string", lhs: "{{name}}", operator: "lengthGte", rhs: 3.0, want: "true"},
+		{name: "lengthLt on an object", lhs: "{{profile}}", operator: "lengthLt", rhs: 3.0, want: "true"},
+		{name: "lengthNe", lhs: "{{tags}}", operator: "lengthNe", rhs: 2.0, want: "false"},
+		{name: "lengthLte", lhs: "{{tags}}", operator: "lengthLte", rhs: 1.0, want: "false"},
+		{name: "length of a number", lhs: "{{age}}", operator: "lengthEq", rhs: 2.0, err: "operator lengthEq requires a string, array or object lhs, got float64"},
+		{name: "length against text", lhs: "{{tags}}", operator: "lengthEq", rhs: "two", err: "operator lengthEq requires a number rhs, got string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := newCondition(map[string]interface{}{"lhs": tt.lhs, "operator": tt.operator, "rhs": tt.rhs})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := node.Execute(ctx)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Execute() = %q, %v, want error %q", result.Output, err, tt.err)
+				}
+				return
+			}
+			if err != nil || result.Output != tt.want {
+				t.Errorf("Execute() = %q, %v, want %q", result.Output, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionBuildErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator string
+		rhs      interface{}
+		err      string // empty when the node is valid
+	}{
+		{name: "contains a number", operator: "contains", rhs: 5.0, err: "operator contains requires a string rhs, got float64"},
+		{name: "startsWith a boolean", operator: "startsWith", rhs: true, err: "operator startsWith requires a string rhs, got bool"},
+		{name: "endsWith an array", operator: "endsWith", rhs: []interface{}{"a"}, err: "operator endsWith requires a string rhs, got []interface {}"},
+		{name: "contains null", operator: "contains", rhs: nil, err: "operator contains requires a string rhs, got null"},
+		{name: "contains a template", operator: "contains", rhs: "{{tag}}"},
+		{name: "endsWith a string", operator: "endsWith", rhs: ".com"},
+		{name: "rhs object", operator: "==", rhs: map[string]interface{}{"a": 1.0}, err: "rhs must be a string, number, boolean or array"},
+		{name: "invalid duration", operator: "olderThan", rhs: "7 days", err: "invalid duration"},
+		{name: "templated duration", operator: "olderThan", rhs: "{{maxAge}}"},
+		{name: "invalid pattern", operator: "matches", rhs: "([a-z]", err: "error parsing regexp"},
+		{name: "unknown operator", operator: "like", rhs: "a%", err: "unknown operator: like"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newCondition(map[string]interface{}{"lhs": "{{value}}", "operator": tt.operator, "rhs": tt.rhs})
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("NewConditionNode() error = %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("NewConditionNode() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
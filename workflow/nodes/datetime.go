@@ -0,0 +1,173 @@
+package nodes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// clock returns the current time for now, today and the olderThan and
+// newerThan operators. Tests replace it to get a fixed time.
+var clock = time.Now
+
+// timeLayouts are the string formats recognised as dates, most specific first.
+var timeLayouts = []string{time.RFC3339Nano, time.RFC3339, "2006-01-02"}
+
+// toTime converts time.Time, BSON dates and RFC3339 (or YYYY-MM-DD) strings to a time.
+func toTime(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, true
+	case *time.Time:
+		if val != nil {
+			return *val, true
+		}
+	case primitive.DateTime:
+		return val.Time(), true
+	case string:
+		return parseTime(val)
+	}
+	return time.Time{}, false
+}
+
+func parseTime(value string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// isTimeValue reports whether v is an actual date value rather than a string.
+func isTimeValue(v interface{}) bool {
+	switch v.(type) {
+	case time.Time, *time.Time, primitive.DateTime:
+		return true
+	}
+	return false
+}
+
+// orderTimes compares two values as dates when both are date values. The
+// loose coercion policy also parses date strings, so a date compares with a
+// date string and two date strings compare chronologically.
+func orderTimes(lhs interface{}, rhs interface{}, coercion CoercionPolicy) (int, bool) {
+	bothTimes := isTimeValue(lhs) && isTimeValue(rhs)
+	if !bothTimes && coercion == CoercionStrict {
+		return 0, false
+	}
+	_, lhsIsStr := lhs.(string)
+	_, rhsIsStr := rhs.(string)
+	if !bothTimes && !isTimeValue(lhs) && !isTimeValue(rhs) && !(lhsIsStr && rhsIsStr) {
+		return 0, false
+	}
+	lhsTime, lhsOk := toTime(lhs)
+	rhsTime, rhsOk := toTime(rhs)
+	if !lhsOk || !rhsOk {
+		return 0, false
+	}
+	return lhsTime.Compare(rhsTime), true
+}
+
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// parseDuration parses durations like "7d", "1w", "36h" or "1d12h30m". It
+// extends time.ParseDuration with days (d) and weeks (w).
+func parseDuration(value string) (time.Duration, error) {
+	text := strings.TrimSpace(value)
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "-"), "+")
+	if text == "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	for text != "" {
+		i := 0
+		for i < len(text) && (isDigit(text[i]) || text[i] == '.') {
+			i++
+		}
+		j := i
+		for j < len(text) && text[j] >= 'a' && text[j] <= 'z' {
+			j++
+		}
+		number, err := strconv.ParseFloat(text[:i], 64)
+		unit, known := durationUnits[text[i:j]]
+		if err != nil || !known {
+			return 0, fmt.Errorf("invalid duration %q (use units w, d, h, m, s, ms)", value)
+		}
+		total += time.Duration(number * float64(unit))
+		text = text[j:]
+	}
+
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+// toDuration accepts a duration string or a number of seconds.
+func toDuration(v interface{}) (time.Duration, bool) {
+	if str, ok := v.(string); ok {
+		d, err := parseDuration(str)
+		return d, err == nil
+	}
+	if seconds, ok := toFloat64(v); ok {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// compareTimes implements the before, after, olderThan and newerThan operators.
+func compareTimes(lhs interface{}, rhs interface{}, operator string) (bool, error) {
+	lhsTime, ok := toTime(lhs)
+	if !ok {
+		return false, fmt.Errorf("operator %s requires a date lhs (date value or RFC3339 string), got %s", operator, describeType(lhs))
+	}
+
+	switch operator {
+	case "before", "after":
+		rhsTime, ok := toTime(rhs)
+		if !ok {
+			return false, fmt.Errorf("operator %s requires a date rhs (date value or RFC3339 string), got %s", operator, describeType(rhs))
+		}
+		if operator == "before" {
+			return lhsTime.Before(rhsTime), nil
+		}
+		return lhsTime.After(rhsTime), nil
+	default:
+		age, ok := toDuration(rhs)
+		if !ok {
+			return false, fmt.Errorf("operator %s requires a duration rhs such as \"7d\" or \"12h\", got %v", operator, rhs)
+		}
+		cutoff := clock().Add(-age)
+		if operator == "olderThan" {
+			return lhsTime.Before(cutoff), nil
+		}
+		return lhsTime.After(cutoff), nil
+	}
+}
+
+// builtinVariable returns values that are available in every template and
+// expression unless the context defines a variable with the same name.
+func builtinVariable(name string) (interface{}, bool) {
+	switch name {
+	case "now":
+		return clock().UTC(), true
+	case "today":
+		return clock().UTC().Truncate(24 * time.Hour), true
+	}
+	return nil, false
+}
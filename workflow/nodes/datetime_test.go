@@ -0,0 +1,131 @@
+package nodes
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// useClock makes clock return now until the test ends.
+func useClock(t *testing.T, now time.Time) {
+	t.Helper()
+	previous := clock
+	clock = func() time.Time { return now }
+	t.Cleanup(func() { clock = previous })
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		err   bool
+	}{
+		{value: "7d", want: 7 * 24 * time.Hour},
+		{value: "1w", want: 7 * 24 * time.Hour},
+		{value: "36h", want: 36 * time.Hour},
+		{value: "1d12h30m", want: 36*time.Hour + 30*time.Minute},
+		{value: "1.5h", want: 90 * time.Minute},
+		{value: "500ms", want: 500 * time.Millisecond},
+		{value: " 90s ", want: 90 * time.Second},
+		{value: "-2h", want: -2 * time.Hour},
+		{value: "+1w", want: 7 * 24 * time.Hour},
+		{value: "", err: true},
+		{value: "-", err: true},
+		{value: "7", err: true},
+		{value: "d", err: true},
+		{value: "7x", err: true},
+		{value: "7D", err: true},
+		{value: "7 days", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDuration(tt.value)
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseDuration() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseDuration() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareTimes(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	useClock(t, now)
+
+	june1 := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		lhs      interface{}
+		operator string
+		rhs      interface{}
+		want     bool
+		err      string
+	}{
+		{name: "before with RFC3339 strings", lhs: "2025-06-01T00:00:00Z", operator: "before", rhs: "2025-06-02T00:00:00Z", want: true},
+		{name: "after with RFC3339 strings", lhs: "2025-06-01T00:00:00Z", operator: "after", rhs: "2025-06-02T00:00:00Z", want: false},
+		{name: "RFC3339 offsets", lhs: "2025-06-01T02:00:00+02:00", operator: "before", rhs: "2025-06-01T00:30:00Z", want: true},
+		{name: "date only string", lhs: "2025-06-01", operator: "before", rhs: "2025-06-01T00:00:01Z", want: true},
+		{name: "BSON date against a string", lhs: primitive.NewDateTimeFromTime(june1), operator: "after", rhs: "2025-05-31T23:59:59Z", want: true},
+		{name: "time against a BSON date", lhs: june1, operator: "before", rhs: primitive.NewDateTimeFromTime(june1.Add(time.Millisecond)), want: true},
+		{name: "equal times are not before", lhs: june1, operator: "before", rhs: "2025-06-01T00:00:00Z", want: false},
+		{name: "olderThan days", lhs: "2025-06-01T00:00:00Z", operator: "olderThan", rhs: "7d", want: true},
+		{name: "newerThan days", lhs: "2025-06-01T00:00:00Z", operator: "newerThan", rhs: "7d", want: false},
+		{name: "olderThan a week", lhs: primitive.NewDateTimeFromTime(now.Add(-8 * 24 * time.Hour)), operator: "olderThan", rhs: "1w", want: true},
+		{name: "newerThan a week", lhs: now.Add(-6 * 24 * time.Hour), operator: "newerThan", rhs: "1w", want: true},
+		{name: "duration in seconds", lhs: now.Add(-30 * time.Minute), operator: "newerThan", rhs: 3600.0, want: true},
+		{name: "lhs not a date", lhs: "yesterday", operator: "before", rhs: "2025-06-01", err: "operator before requires a date lhs"},
+		{name: "lhs a number", lhs: 1717200000.0, operator: "olderThan", rhs: "7d", err: "operator olderThan requires a date lhs"},
+		{name: "rhs not a date", lhs: june1, operator: "after", rhs: "soon", err: "operator after requires a date rhs"},
+		{name: "invalid duration", lhs: june1, operator: "olderThan", rhs: "7 days", err: "operator olderThan requires a duration rhs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compareTimes(tt.lhs, tt.rhs, tt.operator)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("compareTimes() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("compareTimes() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateBuiltins(t *testing.T) {
+	now := time.Date(2025, 6, 15, 14, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	useClock(t, now)
+
+	tests := []struct {
+		name     string
+		template string
+		ctx      map[string]interface{}
+		want     interface{}
+	}{
+		{"now", "{{now}}", nil, time.Date(2025, 6, 15, 12, 30, 0, 0, time.UTC)},
+		{"today", "{{today}}", nil, time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)},
+		{"now in a string", "Created at {{now}}", nil, "Created at 2025-06-15T12:30:00Z"},
+		{"now function", `{{addDuration(now(), "1d")}}`, nil, time.Date(2025, 6, 16, 12, 30, 0, 0, time.UTC)},
+		{"context now wins", "{{now}}", map[string]interface{}{"now": "frozen"}, "frozen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolver{}.ResolveString(tt.template, tt.ctx)
+			if err != nil || got != tt.want {
+				t.Errorf("ResolveString(%q) = %v, %v, want %v", tt.template, got, err, tt.want)
+			}
+		})
+	}
+}
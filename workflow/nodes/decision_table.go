@@ -0,0 +1,509 @@
+package nodes
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+// DecisionTableInput is an input column; Expression computes its value.
+type DecisionTableInput struct {
+	Name       string
+	Expression *Expression
+}
+
+// DecisionTableRule is one row of the table. When holds a cell per input
+// column, in the order of the table's inputs.
+type DecisionTableRule struct {
+	Name     string
+	When     []tableCell
+	Then     map[string]interface{}
+	Priority float64
+}
+
+// DecisionTableNode evaluates its input columns, finds the rules whose cells
+// all match and writes their output columns into the context.
+//
+// Hit policies:
+//   - "first" (default): the first matching rule in table order wins
+//   - "unique": at most one rule may match, overlapping rules are rejected
+//   - "priority": the matching rule with the highest priority wins
+//   - "collect": every matching rule contributes, outputs become arrays
+type DecisionTableNode struct {
+	ID             string
+	Inputs         []DecisionTableInput
+	OutputColumns  []string
+	Rules          []DecisionTableRule
+	HitPolicy      string
+	DefaultOutputs map[string]interface{}
+	Coercion       CoercionPolicy
+	// Resolver holds the parsed call placeholders of the output templates
+	Resolver Resolver
+}
+
+func NewDecisionTableNode(def workflow.NodeDefinition) (*DecisionTableNode, error) {
+	coercion, err := coercionFromConfig(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs, err := parseTableInputs(def.Config["inputs"], coercion)
+	if err != nil {
+		return nil, err
+	}
+
+	outputsValue, ok := def.Config["outputs"].([]interface{})
+	if !ok || len(outputsValue) == 0 {
+		return nil, fmt.Errorf("outputs must be a non-empty array of column names")
+	}
+	outputColumns := make([]string, 0, len(outputsValue))
+	for _, columnValue := range outputsValue {
+		column, ok := columnValue.(string)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("output column names must be non-empty strings")
+		}
+		if column == workflow.NodesKey {
+			return nil, fmt.Errorf("output column %s is reserved for node records", column)
+		}
+		if slices.Contains(outputColumns, column) {
+			return nil, fmt.Errorf("duplicate output column: %s", column)
+		}
+		outputColumns = append(outputColumns, column)
+	}
+
+	hitPolicy := "first"
+	if policyValue, exists := def.Config["hitPolicy"]; exists {
+		policy, ok := policyValue.(string)
+		if !ok {
+			return nil, fmt.Errorf("hitPolicy must be a string")
+		}
+		hitPolicy = policy
+	}
+	switch hitPolicy {
+	case "first", "unique", "priority", "collect":
+	default:
+		return nil, fmt.Errorf("unknown hit policy: %s", hitPolicy)
+	}
+
+	var defaultOutputs map[string]interface{}
+	if defaultsValue, exists := def.Config["defaultOutputs"]; exists {
+		defaults, ok := defaultsValue.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("defaultOutputs must be an object")
+		}
+		for column := range defaults {
+			if !slices.Contains(outputColumns, column) {
+				return nil, fmt.Errorf("defaultOutputs: unknown output column %s", column)
+			}
+		}
+		defaultOutputs = defaults
+	}
+
+	rulesValue, ok := def.Config["rules"].([]interface{})
+	if !ok || len(rulesValue) == 0 {
+		return nil, fmt.Errorf("rules must be a non-empty array")
+	}
+	rules := make([]DecisionTableRule, 0, len(rulesValue))
+	for i, ruleValue := range rulesValue {
+		rule, err := parseTableRule(ruleValue, inputs, outputColumns, hitPolicy == "priority", coercion)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	resolver := Resolver{Missing: MissingError}
+	if err := resolver.Prepare(defaultOutputs); err != nil {
+		return nil, fmt.Errorf("defaultOutputs: %w", err)
+	}
+	for i, rule := range rules {
+		if err := resolver.Prepare(rule.Then); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+
+	node := &DecisionTableNode{
+		ID:             def.ID,
+		Inputs:         inputs,
+		OutputColumns:  outputColumns,
+		Rules:          rules,
+		HitPolicy:      hitPolicy,
+		DefaultOutputs: defaultOutputs,
+		Coercion:       coercion,
+		Resolver:       resolver,
+	}
+	if err := node.checkRules(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// parseTableInputs accepts column names ("age") or objects with a name and
+// an expression ({"name": "age", "expression": "user.age"}).
+func parseTableInputs(value interface{}, coercion CoercionPolicy) ([]DecisionTableInput, error) {
+	inputsValue, ok := value.([]interface{})
+	if !ok || len(inputsValue) == 0 {
+		return nil, fmt.Errorf("inputs must be a non-empty array")
+	}
+
+	inputs := make([]DecisionTableInput, 0, len(inputsValue))
+	seen := make(map[string]bool)
+	for i, inputValue := range inputsValue {
+		var name, source string
+		switch val := inputValue.(type) {
+		case string:
+			name, source = val, val
+		case map[string]interface{}:
+			name, _ = val["name"].(string)
+			source = name
+			if expressionValue, exists := val["expression"]; exists {
+				if source, ok = expressionValue.(string); !ok {
+					return nil, fmt.Errorf("input %d: expression must be a string", i+1)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("input %d must be a string or an object", i+1)
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("input %d: name is required", i+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate input column: %s", name)
+		}
+		seen[name] = true
+
+		expression, err := ParseExpression(source)
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %w", name, err)
+		}
+		inputs = append(inputs, DecisionTableInput{Name: name, Expression: expression.WithCoercion(coercion)})
+	}
+	return inputs, nil
+}
+
+func parseTableRule(value interface{}, inputs []DecisionTableInput, outputColumns []string, needsPriority bool, coercion CoercionPolicy) (DecisionTableRule, error) {
+	ruleMap, ok := value.(map[string]interface{})
+	if !ok {
+		return DecisionTableRule{}, fmt.Errorf("must be an object")
+	}
+
+	rule := DecisionTableRule{When: make([]tableCell, len(inputs))}
+	if nameValue, exists := ruleMap["name"]; exists {
+		if rule.Name, ok = nameValue.(string); !ok {
+			return rule, fmt.Errorf("name must be a string")
+		}
+	}
+
+	when := map[string]interface{}{}
+	if whenValue, exists := ruleMap["when"]; exists {
+		if when, ok = whenValue.(map[string]interface{}); !ok {
+			return rule, fmt.Errorf("when must be an object")
+		}
+	}
+	for column := range when {
+		if tableInputIndex(inputs, column) < 0 {
+			return rule, fmt.Errorf("unknown input column %s", column)
+		}
+	}
+	for i, input := range inputs {
+		cell, err := parseTableCell(when[input.Name], coercion)
+		if err != nil {
+			return rule, fmt.Errorf("%s: %w", input.Name, err)
+		}
+		rule.When[i] = cell
+	}
+
+	then, ok := ruleMap["then"].(map[string]interface{})
+	if !ok {
+		return rule, fmt.Errorf("then must be an object")
+	}
+	for column := range then {
+		if !slices.Contains(outputColumns, column) {
+			return rule, fmt.Errorf("unknown output column %s", column)
+		}
+	}
+	rule.Then = then
+
+	if priorityValue, exists := ruleMap["priority"]; exists {
+		if rule.Priority, ok = priorityValue.(float64); !ok {
+			return rule, fmt.Errorf("priority must be a number")
+		}
+	} else if needsPriority {
+		return rule, fmt.Errorf("priority is required for hit policy priority")
+	}
+
+	return rule, nil
+}
+
+func tableInputIndex(inputs []DecisionTableInput, name string) int {
+	for i, input := range inputs {
+		if input.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkRules rejects tables whose rules provably overlap where the hit
+// policy forbids it, rules that can never match, and, when no defaultOutputs
+// are configured, tables with inputs no rule matches.
+func (n *DecisionTableNode) checkRules() error {
+	for j := range n.Rules {
+		for i := 0; i < j; i++ {
+			if !n.rulesOverlap(i, j) {
+				continue
+			}
+			switch n.HitPolicy {
+			case "unique":
+				return fmt.Errorf("rules %s and %s overlap, which hit policy unique does not allow", n.ruleLabel(i), n.ruleLabel(j))
+			case "first":
+				if n.ruleCovers(i, j) {
+					return fmt.Errorf("rule %s can never match: rule %s matches every input it does", n.ruleLabel(j), n.ruleLabel(i))
+				}
+			case "priority":
+				if n.Rules[i].Priority == n.Rules[j].Priority {
+					return fmt.Errorf("rules %s and %s overlap with the same priority %v", n.ruleLabel(i), n.ruleLabel(j), n.Rules[i].Priority)
+				}
+				if winner, loser := n.priorityOrder(i, j); n.ruleCovers(winner, loser) {
+					return fmt.Errorf("rule %s can never match: rule %s has a higher priority and matches every input it does", n.ruleLabel(loser), n.ruleLabel(winner))
+				}
+			}
+		}
+	}
+
+	if n.DefaultOutputs == nil {
+		gap, found, err := n.findGap()
+		if err != nil {
+			return err
+		}
+		if found {
+			return fmt.Errorf("no rule matches %s: add a rule for it or set defaultOutputs", gap)
+		}
+	}
+	return nil
+}
+
+func (n *DecisionTableNode) rulesOverlap(i, j int) bool {
+	for column := range n.Inputs {
+		if !n.Rules[i].When[column].overlaps(n.Rules[j].When[column]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *DecisionTableNode) ruleCovers(i, j int) bool {
+	for column := range n.Inputs {
+		if !n.Rules[i].When[column].covers(n.Rules[j].When[column]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *DecisionTableNode) priorityOrder(i, j int) (int, int) {
+	if n.Rules[j].Priority > n.Rules[i].Priority {
+		return j, i
+	}
+	return i, j
+}
+
+// maxGapCombinations caps the combinations of cell regions findGap checks.
+const maxGapCombinations = 100000
+
+// findGap returns the first input combination no rule matches. A table whose
+// rules constrain a single numeric column is checked span by span. Otherwise
+// every constrained column is split into regions that each cell matches
+// entirely or not at all (see cellRegions) and every combination of regions
+// must be matched by some rule. Tables that cannot be checked this way are
+// rejected, since they could fall through every rule unnoticed.
+func (n *DecisionTableNode) findGap() (string, bool, error) {
+	var constrained []int
+	for column := range n.Inputs {
+		for _, rule := range n.Rules {
+			if rule.When[column].kind != cellAny {
+				constrained = append(constrained, column)
+				break
+			}
+		}
+	}
+	if len(constrained) == 0 {
+		return "", false, nil
+	}
+
+	if cells := n.columnCells(constrained[0]); len(constrained) == 1 && numericCells(cells) {
+		gap, found := findNumericGap(n.Inputs[constrained[0]].Name, cells)
+		return gap, found, nil
+	}
+
+	regions := make([][]tableCell, len(constrained))
+	combinations := 1
+	for i, column := range constrained {
+		columnRegions, ok := cellRegions(n.columnCells(column))
+		if !ok {
+			return "", false, fmt.Errorf("input %s compares ranges of values other than numbers, so missing rules cannot be checked: set defaultOutputs", n.Inputs[column].Name)
+		}
+		regions[i] = columnRegions
+		combinations *= len(columnRegions)
+		if combinations > maxGapCombinations {
+			return "", false, fmt.Errorf("inputs have too many value combinations to check for missing rules: set defaultOutputs")
+		}
+	}
+
+	index := make([]int, len(constrained))
+	for {
+		if !n.regionMatched(constrained, regions, index) {
+			conditions := make([]string, len(constrained))
+			for i, column := range constrained {
+				conditions[i] = regions[i][index[i]].describeRegion(n.Inputs[column].Name)
+			}
+			return strings.Join(conditions, " and "), true, nil
+		}
+
+		i := len(index) - 1
+		for ; i >= 0; i-- {
+			index[i]++
+			if index[i] < len(regions[i]) {
+				break
+			}
+			index[i] = 0
+		}
+		if i < 0 {
+			return "", false, nil
+		}
+	}
+}
+
+// columnCells returns every rule's cell for the given input column.
+func (n *DecisionTableNode) columnCells(column int) []tableCell {
+	cells := make([]tableCell, len(n.Rules))
+	for i, rule := range n.Rules {
+		cells[i] = rule.When[column]
+	}
+	return cells
+}
+
+// regionMatched reports whether some rule matches every value of the given
+// combination of regions, one per constrained column.
+func (n *DecisionTableNode) regionMatched(constrained []int, regions [][]tableCell, index []int) bool {
+	for _, rule := range n.Rules {
+		matched := true
+		for i, column := range constrained {
+			if !rule.When[column].covers(regions[i][index[i]]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *DecisionTableNode) ruleLabel(i int) string {
+	if n.Rules[i].Name != "" {
+		return fmt.Sprintf("%q", n.Rules[i].Name)
+	}
+	return fmt.Sprintf("%d", i+1)
+}
+
+func (n *DecisionTableNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	values := make([]interface{}, len(n.Inputs))
+	for i, input := range n.Inputs {
+		value, err := input.Expression.Evaluate(ctx)
+		if err != nil {
+			return workflow.NodeResult{}, fmt.Errorf("input %s: %w", input.Name, err)
+		}
+		values[i] = value
+	}
+
+	var matched []int
+	for i, rule := range n.Rules {
+		ok, err := ruleMatches(rule, values)
+		if err != nil {
+			return workflow.NodeResult{}, fmt.Errorf("rule %s: %w", n.ruleLabel(i), err)
+		}
+		if !ok {
+			continue
+		}
+		matched = append(matched, i)
+		if n.HitPolicy == "first" {
+			break
+		}
+	}
+
+	switch {
+	case len(matched) > 1 && n.HitPolicy == "unique":
+		return workflow.NodeResult{}, fmt.Errorf("rules %s and %s both matched, which hit policy unique does not allow", n.ruleLabel(matched[0]), n.ruleLabel(matched[1]))
+	case len(matched) > 1 && n.HitPolicy == "priority":
+		best := matched[0]
+		for _, i := range matched[1:] {
+			if n.Rules[i].Priority > n.Rules[best].Priority {
+				best = i
+			}
+		}
+		matched = []int{best}
+	}
+
+	if len(matched) == 0 {
+		if n.DefaultOutputs == nil {
+			return workflow.NodeResult{Output: "noMatch"}, nil
+		}
+		return n.writeOutputs([]map[string]interface{}{n.DefaultOutputs}, ctx)
+	}
+
+	rows := make([]map[string]interface{}, len(matched))
+	for i, ruleIndex := range matched {
+		rows[i] = n.Rules[ruleIndex].Then
+	}
+	return n.writeOutputs(rows, ctx)
+}
+
+func ruleMatches(rule DecisionTableRule, values []interface{}) (bool, error) {
+	for i, cell := range rule.When {
+		ok, err := cell.matches(values[i])
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// writeOutputs resolves the output templates of the selected rows. With hit
+// policy collect every output column becomes an array with one entry per row.
+func (n *DecisionTableNode) writeOutputs(rows []map[string]interface{}, ctx map[string]interface{}) (workflow.NodeResult, error) {
+	resolved := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		values, err := n.Resolver.ResolveMap(row, ctx)
+		if err != nil {
+			return workflow.NodeResult{}, err
+		}
+		resolved[i] = values
+	}
+
+	data := make(map[string]interface{})
+	if n.HitPolicy == "collect" {
+		for _, column := range n.OutputColumns {
+			collected := make([]interface{}, len(resolved))
+			for i, values := range resolved {
+				collected[i] = values[column]
+			}
+			data[column] = collected
+		}
+	} else {
+		data = resolved[0]
+	}
+
+	return workflow.NodeResult{
+		Output: "default",
+		Data:   data,
+	}, nil
+}
+
+func (n *DecisionTableNode) Outputs() []string {
+	return []string{"default", "noMatch"}
+}
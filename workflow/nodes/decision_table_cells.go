@@ -0,0 +1,564 @@
+package nodes
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type cellKind int
+
+const (
+	cellAny   cellKind = iota // "-" or empty, matches everything
+	cellSet                   // literal, array or "== v"; "!= v" when negated
+	cellRange                 // "> v", "<= v" or "[a..b]"
+)
+
+// tableBound is one end of a range cell; a nil value means unbounded.
+type tableBound struct {
+	value     interface{}
+	inclusive bool
+}
+
+// tableCell is a parsed decision table condition. Cells are literals rather
+// than templates so overlaps and gaps can be checked when the table is built.
+type tableCell struct {
+	source   string
+	kind     cellKind
+	values   []interface{}
+	negated  bool
+	lo, hi   tableBound
+	coercion CoercionPolicy
+}
+
+// parseTableCell parses a cell such as "-", "USA", ">= 18", "!= 0",
+// "[18..65)", a JSON number or boolean, or an array of allowed values.
+// Inputs are matched against the cell using the coercion policy.
+func parseTableCell(value interface{}, coercion CoercionPolicy) (tableCell, error) {
+	cell, err := parseCellValue(value)
+	cell.coercion = coercion
+	return cell, err
+}
+
+func parseCellValue(value interface{}) (tableCell, error) {
+	switch val := value.(type) {
+	case nil:
+		return tableCell{source: "-", kind: cellAny}, nil
+	case float64, bool:
+		return tableCell{source: formatTemplateValue(val), kind: cellSet, values: []interface{}{val}}, nil
+	case []interface{}:
+		for _, item := range val {
+			switch item.(type) {
+			case nil, string, float64, bool:
+			default:
+				return tableCell{}, fmt.Errorf("array cells may only contain strings, numbers, booleans or null")
+			}
+		}
+		return tableCell{source: formatTemplateValue(val), kind: cellSet, values: val}, nil
+	case string:
+		return parseCellString(val)
+	default:
+		return tableCell{}, fmt.Errorf("cell must be a string, number, boolean or array")
+	}
+}
+
+func parseCellString(source string) (tableCell, error) {
+	text := strings.TrimSpace(source)
+	cell := tableCell{source: text}
+
+	if text == "" || text == "-" {
+		cell.kind = cellAny
+		return cell, nil
+	}
+
+	if strings.Contains(text, "..") && strings.ContainsAny(text[:1], "[(") && strings.ContainsAny(text[len(text)-1:], "])") {
+		bounds := strings.SplitN(text[1:len(text)-1], "..", 2)
+		lo, err := parseCellLiteral(bounds[0])
+		if err != nil {
+			return cell, err
+		}
+		hi, err := parseCellLiteral(bounds[1])
+		if err != nil {
+			return cell, err
+		}
+		if lo == nil || hi == nil {
+			return cell, fmt.Errorf("range %s cannot have null bounds", text)
+		}
+		cell.kind = cellRange
+		cell.lo = tableBound{value: lo, inclusive: text[0] == '['}
+		cell.hi = tableBound{value: hi, inclusive: text[len(text)-1] == ']'}
+		if !rangeNonEmpty(cell.lo, cell.hi) {
+			return cell, fmt.Errorf("range %s is empty", text)
+		}
+		return cell, nil
+	}
+
+	for _, operator := range []string{">=", "<=", "!=", "==", ">", "<"} {
+		if !strings.HasPrefix(text, operator) {
+			continue
+		}
+		value, err := parseCellLiteral(text[len(operator):])
+		if err != nil {
+			return cell, err
+		}
+		if value == nil && operator != "==" && operator != "!=" {
+			return cell, fmt.Errorf("operator %s cannot compare with null", operator)
+		}
+		switch operator {
+		case "==", "!=":
+			cell.kind = cellSet
+			cell.values = []interface{}{value}
+			cell.negated = operator == "!="
+		case ">", ">=":
+			cell.kind = cellRange
+			cell.lo = tableBound{value: value, inclusive: operator == ">="}
+		default:
+			cell.kind = cellRange
+			cell.hi = tableBound{value: value, inclusive: operator == "<="}
+		}
+		return cell, nil
+	}
+
+	value, err := parseCellLiteral(text)
+	if err != nil {
+		return cell, err
+	}
+	cell.kind = cellSet
+	cell.values = []interface{}{value}
+	return cell, nil
+}
+
+// parseCellLiteral reads a quoted string, number, true, false or null, and
+// takes any other text as a bare string.
+func parseCellLiteral(source string) (interface{}, error) {
+	text := strings.TrimSpace(source)
+	if text == "" {
+		return nil, fmt.Errorf("missing value in cell")
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		value, end, err := lexString(text)
+		if err != nil {
+			return nil, err
+		}
+		if end != len(text) {
+			return nil, fmt.Errorf("unexpected text after string in cell %q", source)
+		}
+		return value, nil
+	}
+	switch text {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if number, err := strconv.ParseFloat(text, 64); err == nil {
+		return number, nil
+	}
+	return text, nil
+}
+
+// matches reports whether an input value satisfies the cell.
+func (c tableCell) matches(value interface{}) (bool, error) {
+	switch c.kind {
+	case cellAny:
+		return true, nil
+	case cellSet:
+		return sliceContains(c.values, value, c.coercion) != c.negated, nil
+	default:
+		if c.lo.value != nil {
+			order, err := orderValues(value, c.lo.value, c.source, c.coercion)
+			if err != nil {
+				return false, err
+			}
+			if order < 0 || (order == 0 && !c.lo.inclusive) {
+				return false, nil
+			}
+		}
+		if c.hi.value != nil {
+			order, err := orderValues(value, c.hi.value, c.source, c.coercion)
+			if err != nil {
+				return false, err
+			}
+			if order > 0 || (order == 0 && !c.hi.inclusive) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
+
+// inRange reports whether a literal lies within a range cell. Values that
+// cannot be ordered against the bounds are outside it.
+func (c tableCell) inRange(value interface{}) bool {
+	matched, err := c.matches(value)
+	return err == nil && matched
+}
+
+// overlaps reports whether some input value could satisfy both cells.
+func (c tableCell) overlaps(other tableCell) bool {
+	if c.kind == cellAny || other.kind == cellAny {
+		return true
+	}
+	if c.kind == cellRange && other.kind == cellSet {
+		return other.overlaps(c)
+	}
+
+	if c.kind == cellSet && other.kind == cellSet {
+		switch {
+		case !c.negated && !other.negated:
+			return anyValue(c.values, func(v interface{}) bool { return sliceContains(other.values, v, c.coercion) })
+		case !c.negated:
+			return anyValue(c.values, func(v interface{}) bool { return !sliceContains(other.values, v, c.coercion) })
+		case !other.negated:
+			return anyValue(other.values, func(v interface{}) bool { return !sliceContains(c.values, v, c.coercion) })
+		default:
+			return true
+		}
+	}
+
+	if c.kind == cellSet {
+		if !c.negated {
+			return anyValue(c.values, other.inRange)
+		}
+		// Only a single-point range can be excluded entirely
+		return !(rangeIsPoint(other) && sliceContains(c.values, other.lo.value, c.coercion))
+	}
+
+	lo := tighterBound(c.lo, other.lo, 1)
+	hi := tighterBound(c.hi, other.hi, -1)
+	return rangeNonEmpty(lo, hi)
+}
+
+// covers reports whether every value satisfying other also satisfies c.
+func (c tableCell) covers(other tableCell) bool {
+	if c.kind == cellAny {
+		return true
+	}
+	if other.kind == cellAny {
+		return false
+	}
+
+	switch {
+	case c.kind == cellSet && !c.negated:
+		return other.kind == cellSet && !other.negated &&
+			allValues(other.values, func(v interface{}) bool { return sliceContains(c.values, v, c.coercion) })
+	case c.kind == cellSet:
+		if other.kind == cellSet && other.negated {
+			return allValues(c.values, func(v interface{}) bool { return sliceContains(other.values, v, c.coercion) })
+		}
+		if other.kind == cellSet {
+			return allValues(other.values, func(v interface{}) bool { return !sliceContains(c.values, v, c.coercion) })
+		}
+		return allValues(c.values, func(v interface{}) bool { return !other.inRange(v) })
+	case other.kind == cellSet:
+		return !other.negated && allValues(other.values, c.inRange)
+	default:
+		return boundCovers(c.lo, other.lo, 1) && boundCovers(c.hi, other.hi, -1)
+	}
+}
+
+// compareBounds orders two bound values; ok is false when they cannot be
+// ordered. Bounds are literals of the table, so they are never coerced.
+func compareBounds(a interface{}, b interface{}) (int, bool) {
+	order, err := orderValues(a, b, "..", CoercionStrict)
+	return order, err == nil
+}
+
+// tighterBound returns the more restrictive of two lower (direction 1) or
+// upper (direction -1) bounds.
+func tighterBound(a tableBound, b tableBound, direction int) tableBound {
+	if a.value == nil {
+		return b
+	}
+	if b.value == nil {
+		return a
+	}
+	order, ok := compareBounds(a.value, b.value)
+	if !ok {
+		// Incomparable bounds such as a number and a string never overlap
+		return tableBound{value: a.value, inclusive: false}
+	}
+	switch {
+	case order*direction > 0:
+		return a
+	case order*direction < 0:
+		return b
+	default:
+		return tableBound{value: a.value, inclusive: a.inclusive && b.inclusive}
+	}
+}
+
+// boundCovers reports whether lower (direction 1) or upper (direction -1)
+// bound a is at least as loose as b.
+func boundCovers(a tableBound, b tableBound, direction int) bool {
+	if a.value == nil {
+		return true
+	}
+	if b.value == nil {
+		return false
+	}
+	order, ok := compareBounds(a.value, b.value)
+	if !ok {
+		return false
+	}
+	return order*direction < 0 || (order == 0 && (a.inclusive || !b.inclusive))
+}
+
+func rangeNonEmpty(lo tableBound, hi tableBound) bool {
+	if lo.value == nil || hi.value == nil {
+		return true
+	}
+	order, ok := compareBounds(lo.value, hi.value)
+	if !ok {
+		return false
+	}
+	return order < 0 || (order == 0 && lo.inclusive && hi.inclusive)
+}
+
+func rangeIsPoint(c tableCell) bool {
+	if c.lo.value == nil || c.hi.value == nil {
+		return false
+	}
+	order, ok := compareBounds(c.lo.value, c.hi.value)
+	return ok && order == 0
+}
+
+func anyValue(values []interface{}, predicate func(interface{}) bool) bool {
+	for _, value := range values {
+		if predicate(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func allValues(values []interface{}, predicate func(interface{}) bool) bool {
+	for _, value := range values {
+		if !predicate(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// numericSpans converts a cell over numbers into the intervals it matches.
+// ok is false when the cell involves non-numeric values.
+func (c tableCell) numericSpans() ([]tableCell, bool) {
+	switch c.kind {
+	case cellAny:
+		return []tableCell{c}, true
+	case cellRange:
+		if !isNumericBound(c.lo) || !isNumericBound(c.hi) {
+			return nil, false
+		}
+		return []tableCell{c}, true
+	}
+
+	points := make([]float64, 0, len(c.values))
+	for _, value := range c.values {
+		number, ok := value.(float64)
+		if !ok {
+			return nil, false
+		}
+		points = append(points, number)
+	}
+	sort.Float64s(points)
+
+	var spans []tableCell
+	if !c.negated {
+		for _, point := range points {
+			bound := tableBound{value: point, inclusive: true}
+			spans = append(spans, tableCell{kind: cellRange, lo: bound, hi: bound})
+		}
+		return spans, true
+	}
+
+	lo := tableBound{}
+	for _, point := range points {
+		hi := tableBound{value: point}
+		if rangeNonEmpty(lo, hi) {
+			spans = append(spans, tableCell{kind: cellRange, lo: lo, hi: hi})
+		}
+		lo = tableBound{value: point}
+	}
+	return append(spans, tableCell{kind: cellRange, lo: lo}), true
+}
+
+func isNumericBound(bound tableBound) bool {
+	if bound.value == nil {
+		return true
+	}
+	_, ok := bound.value.(float64)
+	return ok
+}
+
+// cellRegions splits the values of a column into regions that each of the
+// cells matches entirely or not at all. When every cell is numeric these are
+// the bounds and listed numbers of the cells and the open intervals between
+// them, otherwise each listed value and one region for every other value.
+// ok is false for ranges over values other than numbers.
+func cellRegions(cells []tableCell) ([]tableCell, bool) {
+	if numericCells(cells) {
+		return numericRegions(cells), true
+	}
+
+	var values []interface{}
+	for _, cell := range cells {
+		if cell.kind == cellRange {
+			return nil, false
+		}
+		for _, value := range cell.values {
+			if !sliceContains(values, value, CoercionStrict) {
+				values = append(values, value)
+			}
+		}
+	}
+	regions := make([]tableCell, 0, len(values)+1)
+	for _, value := range values {
+		regions = append(regions, tableCell{kind: cellSet, values: []interface{}{value}})
+	}
+	return append(regions, tableCell{kind: cellSet, values: values, negated: true}), true
+}
+
+// numericCells reports whether every cell only involves numbers.
+func numericCells(cells []tableCell) bool {
+	for _, cell := range cells {
+		if _, ok := cell.numericSpans(); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// numericRegions returns the open intervals between the bounds and listed
+// numbers of the cells and the bounds themselves, in ascending order.
+func numericRegions(cells []tableCell) []tableCell {
+	var bounds []float64
+	for _, cell := range cells {
+		spans, _ := cell.numericSpans()
+		for _, span := range spans {
+			for _, bound := range []tableBound{span.lo, span.hi} {
+				if bound.value != nil {
+					bounds = append(bounds, bound.value.(float64))
+				}
+			}
+		}
+	}
+	sort.Float64s(bounds)
+	bounds = slices.Compact(bounds)
+
+	regions := make([]tableCell, 0, 2*len(bounds)+1)
+	lo := tableBound{}
+	for _, bound := range bounds {
+		regions = append(regions,
+			tableCell{kind: cellRange, lo: lo, hi: tableBound{value: bound}},
+			tableCell{kind: cellSet, values: []interface{}{bound}},
+		)
+		lo = tableBound{value: bound}
+	}
+	return append(regions, tableCell{kind: cellRange, lo: lo})
+}
+
+// describeRegion renders a region from cellRegions as a condition on column,
+// such as "age < 18", "age in (18..21)", "country == USA" or
+// "country not in ["USA","CAN"]".
+func (c tableCell) describeRegion(column string) string {
+	switch {
+	case c.kind == cellSet && c.negated:
+		return fmt.Sprintf("%s not in %s", column, formatTemplateValue(c.values))
+	case c.kind == cellSet:
+		return fmt.Sprintf("%s == %s", column, formatTemplateValue(c.values[0]))
+	case c.lo.value == nil && c.hi.value == nil:
+		return fmt.Sprintf("any number for %s", column)
+	case c.lo.value == nil:
+		return fmt.Sprintf("%s < %s", column, formatTemplateValue(c.hi.value))
+	case c.hi.value == nil:
+		return fmt.Sprintf("%s > %s", column, formatTemplateValue(c.lo.value))
+	default:
+		return fmt.Sprintf("%s in (%s..%s)", column, formatTemplateValue(c.lo.value), formatTemplateValue(c.hi.value))
+	}
+}
+
+// findNumericGap returns a description of the first number that none of the
+// cells match, such as "age < 18" or "age in (30..40]". ok is false when the
+// cells cover every number or involve non-numeric values.
+func findNumericGap(column string, cells []tableCell) (string, bool) {
+	var spans []tableCell
+	for _, cell := range cells {
+		cellSpans, ok := cell.numericSpans()
+		if !ok {
+			return "", false
+		}
+		spans = append(spans, cellSpans...)
+	}
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		a, b := spans[i].lo, spans[j].lo
+		if a.value == nil || b.value == nil {
+			return a.value == nil && b.value != nil
+		}
+		if a.value.(float64) != b.value.(float64) {
+			return a.value.(float64) < b.value.(float64)
+		}
+		return a.inclusive && !b.inclusive
+	})
+
+	first := spans[0].lo
+	if first.value != nil {
+		if first.inclusive {
+			return fmt.Sprintf("%s < %s", column, formatTemplateValue(first.value)), true
+		}
+		return fmt.Sprintf("%s <= %s", column, formatTemplateValue(first.value)), true
+	}
+
+	reach := spans[0].hi
+	for _, span := range spans[1:] {
+		if reach.value == nil {
+			return "", false
+		}
+		if span.lo.value != nil {
+			if gap, found := describeGap(column, reach, span.lo); found {
+				return gap, true
+			}
+		}
+		if !boundCovers(reach, span.hi, -1) {
+			reach = span.hi
+		}
+	}
+
+	if reach.value == nil {
+		return "", false
+	}
+	if reach.inclusive {
+		return fmt.Sprintf("%s > %s", column, formatTemplateValue(reach.value)), true
+	}
+	return fmt.Sprintf("%s >= %s", column, formatTemplateValue(reach.value)), true
+}
+
+// describeGap describes the values strictly between the upper bound covered
+// so far and the lower bound of the next span, if there are any.
+func describeGap(column string, reach tableBound, next tableBound) (string, bool) {
+	order, _ := compareBounds(reach.value, next.value)
+	if order > 0 || (order == 0 && (reach.inclusive || next.inclusive)) {
+		return "", false
+	}
+	if order == 0 {
+		return fmt.Sprintf("%s == %s", column, formatTemplateValue(reach.value)), true
+	}
+
+	open, close := "[", "]"
+	if reach.inclusive {
+		open = "("
+	}
+	if next.inclusive {
+		close = ")"
+	}
+	return fmt.Sprintf("%s in %s%s..%s%s", column, open, formatTemplateValue(reach.value), formatTemplateValue(next.value), close), true
+}
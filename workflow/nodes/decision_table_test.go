@@ -0,0 +1,262 @@
+package nodes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+func newDecisionTable(config map[string]interface{}) (*DecisionTableNode, error) {
+	return NewDecisionTableNode(workflow.NodeDefinition{ID: "table", Type: "decision_table", Config: config})
+}
+
+// rule builds a rule row; then is a single "result" output value.
+func rule(when map[string]interface{}, result interface{}) map[string]interface{} {
+	return map[string]interface{}{"when": when, "then": map[string]interface{}{"result": result}}
+}
+
+func withPriority(row map[string]interface{}, priority float64) map[string]interface{} {
+	row["priority"] = priority
+	return row
+}
+
+func TestDecisionTableBuildChecks(t *testing.T) {
+	tests := []struct {
+		name      string
+		hitPolicy string
+		rules     []interface{}
+		defaults  map[string]interface{}
+		// err is a substring of the expected build error, empty when the table is valid
+		err string
+	}{
+		{
+			name:      "unique with disjoint rules",
+			hitPolicy: "unique",
+			rules:     []interface{}{rule(map[string]interface{}{"age": "< 18"}, "minor"), rule(map[string]interface{}{"age": ">= 18"}, "adult")},
+		},
+		{
+			name:      "unique with overlapping ranges",
+			hitPolicy: "unique",
+			rules:     []interface{}{rule(map[string]interface{}{"age": "<= 18"}, "minor"), rule(map[string]interface{}{"age": ">= 18"}, "adult")},
+			err:       "rules 1 and 2 overlap, which hit policy unique does not allow",
+		},
+		{
+			name:      "unique with overlapping sets",
+			hitPolicy: "unique",
+			rules: []interface{}{
+				rule(map[string]interface{}{"country": []interface{}{"USA", "CAN"}}, "na"),
+				rule(map[string]interface{}{"country": "!= USA"}, "other"),
+			},
+			err: "rules 1 and 2 overlap",
+		},
+		{
+			name:      "unique overlap on every column",
+			hitPolicy: "unique",
+			rules: []interface{}{
+				rule(map[string]interface{}{"age": "< 30", "country": "USA"}, "young-us"),
+				rule(map[string]interface{}{"age": "> 20", "country": "-"}, "older"),
+			},
+			defaults: map[string]interface{}{"result": "none"},
+			err:      "rules 1 and 2 overlap",
+		},
+		{
+			name:      "unique rules apart on one column",
+			hitPolicy: "unique",
+			rules: []interface{}{
+				rule(map[string]interface{}{"age": "< 30", "country": "USA"}, "young-us"),
+				rule(map[string]interface{}{"age": "> 20", "country": "CAN"}, "older-ca"),
+			},
+			defaults: map[string]interface{}{"result": "none"},
+		},
+		{
+			name:      "first with a covered rule",
+			hitPolicy: "first",
+			rules:     []interface{}{rule(map[string]interface{}{"age": ">= 18"}, "adult"), rule(map[string]interface{}{"age": "> 65"}, "senior"), rule(map[string]interface{}{"age": "< 18"}, "minor")},
+			err:       "rule 2 can never match: rule 1 matches every input it does",
+		},
+		{
+			name:      "first with a partially overlapping rule",
+			hitPolicy: "first",
+			rules:     []interface{}{rule(map[string]interface{}{"age": "> 65"}, "senior"), rule(map[string]interface{}{"age": ">= 18"}, "adult"), rule(map[string]interface{}{"age": "< 18"}, "minor")},
+		},
+		{
+			name:      "priority without priority",
+			hitPolicy: "priority",
+			rules:     []interface{}{rule(map[string]interface{}{"age": "-"}, "any")},
+			err:       "priority is required for hit policy priority",
+		},
+		{
+			name:      "priority overlap with equal priority",
+			hitPolicy: "priority",
+			rules: []interface{}{
+				withPriority(rule(map[string]interface{}{"age": "< 30"}, "young"), 1),
+				withPriority(rule(map[string]interface{}{"age": "> 20"}, "older"), 1),
+			},
+			err: "rules 1 and 2 overlap with the same priority 1",
+		},
+		{
+			name:      "priority rule covered by a higher one",
+			hitPolicy: "priority",
+			rules: []interface{}{
+				withPriority(rule(map[string]interface{}{"age": "> 65"}, "senior"), 1),
+				withPriority(rule(map[string]interface{}{"age": "-"}, "any"), 2),
+			},
+			err: "rule 1 can never match: rule 2 has a higher priority and matches every input it does",
+		},
+		{
+			name:      "priority overlap with different priorities",
+			hitPolicy: "priority",
+			rules: []interface{}{
+				withPriority(rule(map[string]interface{}{"age": "> 65"}, "senior"), 2),
+				withPriority(rule(map[string]interface{}{"age": "-"}, "any"), 1),
+			},
+		},
+		{
+			name:      "collect allows overlaps",
+			hitPolicy: "collect",
+			rules:     []interface{}{rule(map[string]interface{}{"age": "-"}, "any"), rule(map[string]interface{}{"age": "-"}, "also")},
+		},
+		{
+			name:  "gap between numeric ranges",
+			rules: []interface{}{rule(map[string]interface{}{"age": "< 18"}, "minor"), rule(map[string]interface{}{"age": "> 21"}, "adult")},
+			err:   "no rule matches age in [18..21]: add a rule for it or set defaultOutputs",
+		},
+		{
+			name:  "gap at a single number",
+			rules: []interface{}{rule(map[string]interface{}{"age": "< 18"}, "minor"), rule(map[string]interface{}{"age": "> 18"}, "adult")},
+			err:   "no rule matches age == 18",
+		},
+		{
+			name:  "gap below the lowest range",
+			rules: []interface{}{rule(map[string]interface{}{"age": "[0..18)"}, "minor"), rule(map[string]interface{}{"age": ">= 18"}, "adult")},
+			err:   "no rule matches age < 0",
+		},
+		{
+			name:     "gap filled by defaultOutputs",
+			rules:    []interface{}{rule(map[string]interface{}{"age": "< 18"}, "minor")},
+			defaults: map[string]interface{}{"result": "adult"},
+		},
+		{
+			name:  "gap outside listed values",
+			rules: []interface{}{rule(map[string]interface{}{"country": "USA"}, "us"), rule(map[string]interface{}{"country": "CAN"}, "ca")},
+			err:   `no rule matches country not in ["USA","CAN"]`,
+		},
+		{
+			name:  "listed values and everything else",
+			rules: []interface{}{rule(map[string]interface{}{"country": "USA"}, "us"), rule(map[string]interface{}{"country": "!= USA"}, "other")},
+		},
+		{
+			name: "gap across two columns",
+			rules: []interface{}{
+				rule(map[string]interface{}{"age": "< 18"}, "minor"),
+				rule(map[string]interface{}{"age": ">= 18", "country": "USA"}, "us-adult"),
+			},
+			err: `no rule matches age == 18 and country not in ["USA"]`,
+		},
+		{
+			name: "two columns fully covered",
+			rules: []interface{}{
+				rule(map[string]interface{}{"age": "< 18"}, "minor"),
+				rule(map[string]interface{}{"age": ">= 18", "country": "USA"}, "us-adult"),
+				rule(map[string]interface{}{"age": ">= 18", "country": "!= USA"}, "adult"),
+			},
+		},
+		{
+			name: "two numeric columns",
+			rules: []interface{}{
+				rule(map[string]interface{}{"age": "< 18"}, "minor"),
+				rule(map[string]interface{}{"age": ">= 18", "orders": ">= 10"}, "loyal"),
+				rule(map[string]interface{}{"age": "> 18", "orders": "< 10"}, "adult"),
+			},
+			err: "no rule matches age == 18 and orders < 10",
+		},
+		{
+			name:  "ranges over strings cannot be checked",
+			rules: []interface{}{rule(map[string]interface{}{"signup": ">= 2024-01-01"}, "new"), rule(map[string]interface{}{"signup": "< 2024-01-01"}, "old")},
+			err:   "input signup compares ranges of values other than numbers, so missing rules cannot be checked: set defaultOutputs",
+		},
+		{
+			name:     "ranges over strings with defaultOutputs",
+			rules:    []interface{}{rule(map[string]interface{}{"signup": ">= 2024-01-01"}, "new")},
+			defaults: map[string]interface{}{"result": "old"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]interface{}{
+				"inputs":  []interface{}{"age", "country", "orders", "signup"},
+				"outputs": []interface{}{"result"},
+				"rules":   tt.rules,
+			}
+			if tt.hitPolicy != "" {
+				config["hitPolicy"] = tt.hitPolicy
+			}
+			if tt.defaults != nil {
+				config["defaultOutputs"] = tt.defaults
+			}
+
+			_, err := newDecisionTable(config)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("NewDecisionTableNode() error = %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("NewDecisionTableNode() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDecisionTableRejectsReservedOutputColumn(t *testing.T) {
+	_, err := newDecisionTable(map[string]interface{}{
+		"inputs":  []interface{}{"age"},
+		"outputs": []interface{}{"result", "nodes"},
+		"rules":   []interface{}{rule(map[string]interface{}{"age": "-"}, "any")},
+	})
+	if err == nil || err.Error() != "output column nodes is reserved for node records" {
+		t.Errorf("NewDecisionTableNode() error = %v, want the reserved column rejected", err)
+	}
+}
+
+func TestDecisionTableHitPolicies(t *testing.T) {
+	tests := []struct {
+		hitPolicy string
+		rules     []interface{}
+		age       float64
+		want      interface{}
+	}{
+		{"first", []interface{}{rule(map[string]interface{}{"age": "> 65"}, "senior"), rule(map[string]interface{}{"age": ">= 18"}, "adult")}, 70, "senior"},
+		{"first", []interface{}{rule(map[string]interface{}{"age": "> 65"}, "senior"), rule(map[string]interface{}{"age": ">= 18"}, "adult")}, 30, "adult"},
+		{"unique", []interface{}{rule(map[string]interface{}{"age": "[18..65]"}, "adult"), rule(map[string]interface{}{"age": "> 65"}, "senior")}, 65, "adult"},
+		{"priority", []interface{}{
+			withPriority(rule(map[string]interface{}{"age": ">= 18"}, "adult"), 1),
+			withPriority(rule(map[string]interface{}{"age": "> 65"}, "senior"), 5),
+		}, 70, "senior"},
+		{"collect", []interface{}{rule(map[string]interface{}{"age": ">= 18"}, "adult"), rule(map[string]interface{}{"age": "> 65"}, "senior")}, 70, []interface{}{"adult", "senior"}},
+		{"collect", []interface{}{rule(map[string]interface{}{"age": ">= 18"}, "adult"), rule(map[string]interface{}{"age": "> 65"}, "senior")}, 10, []interface{}{"none"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hitPolicy, func(t *testing.T) {
+			table, err := newDecisionTable(map[string]interface{}{
+				"inputs":         []interface{}{"age"},
+				"outputs":        []interface{}{"result"},
+				"hitPolicy":      tt.hitPolicy,
+				"rules":          tt.rules,
+				"defaultOutputs": map[string]interface{}{"result": "none"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := table.Execute(map[string]interface{}{"age": tt.age})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Output != "default" || !reflect.DeepEqual(result.Data["result"], tt.want) {
+				t.Errorf("age %v: %s %v, want %v", tt.age, result.Output, result.Data["result"], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,826 @@
+package nodes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression is a parsed rule expression such as
+//
+//	age >= 18 && (country == "USA" || vip == true)
+//
+// Expressions support the operators of the condition node (== != > < >= <=
+// and the infix words in, notIn, contains, startsWith, endsWith, matches,
+// between, before, after, olderThan and newerThan), boolean && || ! (or
+// and, or, not), arithmetic + - * / %, null coalescing ??, parentheses,
+// string, number, boolean, null and [array] literals, function calls and
+// context variables. Variables are written as paths (user.address.city,
+// similarUsers[0].email) or, for names that are not valid identifiers, as
+// templates ({{nodes.register-user.insertedID}}).
+type Expression struct {
+	source      string
+	root        exprNode
+	coercion    CoercionPolicy
+	nullMissing bool
+}
+
+// ParseExpression parses an expression so that syntax errors surface when
+// the workflow is built rather than when it runs.
+func ParseExpression(source string) (*Expression, error) {
+	tokens, err := lexExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{source: source, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return &Expression{source: source, root: root, coercion: CoercionLoose}, nil
+}
+
+// WithCoercion returns a copy of the expression that compares values using
+// the given coercion policy.
+func (e *Expression) WithCoercion(coercion CoercionPolicy) *Expression {
+	copied := *e
+	copied.coercion = coercion
+	return &copied
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Evaluate evaluates the expression against the context.
+func (e *Expression) Evaluate(ctx map[string]interface{}) (interface{}, error) {
+	value, err := e.root.eval(&evalEnv{ctx: ctx, coercion: e.coercion, nullMissing: e.nullMissing})
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", e.source, err)
+	}
+	return value, nil
+}
+
+// Variables returns the distinct variable paths the expression reads, in
+// order of appearance.
+func (e *Expression) Variables() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	var walk func(node exprNode)
+	walk = func(node exprNode) {
+		switch n := node.(type) {
+		case *variableNode:
+			add(n.path)
+		case *existsNode:
+			add(n.path)
+		case *listNode:
+			for _, item := range n.items {
+				walk(item)
+			}
+		case *unaryNode:
+			walk(n.operand)
+		case *logicalNode:
+			walk(n.left)
+			walk(n.right)
+		case *compareNode:
+			walk(n.left)
+			walk(n.right)
+		case *coalesceNode:
+			walk(n.left)
+			walk(n.right)
+		case *arithmeticNode:
+			walk(n.left)
+			walk(n.right)
+		case *callNode:
+			for _, arg := range n.args {
+				walk(arg)
+			}
+		}
+	}
+	walk(e.root)
+	return paths
+}
+
+// WithMissingAsNull returns a copy of the expression in which missing
+// variables evaluate to null instead of failing.
+func (e *Expression) WithMissingAsNull() *Expression {
+	copied := *e
+	copied.nullMissing = true
+	return &copied
+}
+
+// EvaluateBool evaluates the expression and requires a boolean result.
+func (e *Expression) EvaluateBool(ctx map[string]interface{}) (bool, error) {
+	value, err := e.Evaluate(ctx)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q: result must be a boolean, got %s", e.source, describeType(value))
+	}
+	return result, nil
+}
+
+// evalEnv carries everything an expression needs while being evaluated.
+type evalEnv struct {
+	ctx         map[string]interface{}
+	coercion    CoercionPolicy
+	nullMissing bool
+}
+
+// --- Lexer ---
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenVariable
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value interface{}
+}
+
+var expressionOperators = []string{"??", "&&", "||", "==", "!=", ">=", "<=", "!", ">", "<", "+", "-", "*", "/", "%", "(", ")", "[", "]", ","}
+
+func lexExpression(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case strings.HasPrefix(source[i:], "{{"):
+			end := strings.Index(source[i:], "}}")
+			if end == -1 {
+				return nil, fmt.Errorf("parse error at position %d: unclosed {{", i)
+			}
+			name := strings.TrimSpace(source[i+2 : i+end])
+			tokens = append(tokens, token{kind: tokenVariable, text: name, pos: i})
+			i += end + 2
+
+		case c == '"' || c == '\'':
+			value, length, err := lexString(source[i:])
+			if err != nil {
+				return nil, fmt.Errorf("parse error at position %d: %w", i, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: source[i : i+length], pos: i, value: value})
+			i += length
+
+		case isDigit(c) || (c == '.' && i+1 < len(source) && isDigit(source[i+1])):
+			start := i
+			for i < len(source) && (isDigit(source[i]) || source[i] == '.') {
+				i++
+			}
+			if i < len(source) && (source[i] == 'e' || source[i] == 'E') {
+				i++
+				if i < len(source) && (source[i] == '+' || source[i] == '-') {
+					i++
+				}
+				for i < len(source) && isDigit(source[i]) {
+					i++
+				}
+			}
+			number, err := strconv.ParseFloat(source[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse error at position %d: invalid number %q", start, source[start:i])
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: source[start:i], pos: start, value: number})
+
+		case isIdentStart(rune(c)):
+			start := i
+			i = lexPath(source, i)
+			tokens = append(tokens, token{kind: tokenIdent, text: source[start:i], pos: start})
+
+		default:
+			matched := false
+			for _, op := range expressionOperators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("parse error at position %d: unexpected character %q", i, c)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+// lexPath consumes an identifier together with any .field and [index] suffixes.
+func lexPath(source string, i int) int {
+	for i < len(source) && isIdentPart(rune(source[i])) {
+		i++
+	}
+	for i < len(source) {
+		switch {
+		case source[i] == '.' && i+1 < len(source) && isIdentPart(rune(source[i+1])):
+			i++
+			for i < len(source) && isIdentPart(rune(source[i])) {
+				i++
+			}
+		case source[i] == '[':
+			end := i + 1
+			for end < len(source) && isDigit(source[end]) {
+				end++
+			}
+			if end == i+1 || end >= len(source) || source[end] != ']' {
+				return i
+			}
+			i = end + 1
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func lexString(source string) (string, int, error) {
+	quote := source[0]
+	var b strings.Builder
+	for i := 1; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(source):
+			i++
+			switch source[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(source[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
+
+// --- Parser ---
+
+type exprParser struct {
+	source string
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// match consumes the next token if it is one of the given operators or keywords.
+func (p *exprParser) match(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOperator && tok.kind != tokenIdent {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.match(op); !ok {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return p.errorf(tok, "expected %q but expression ended", op)
+		}
+		return p.errorf(tok, "expected %q, got %q", op, tok.text)
+	}
+	return nil
+}
+
+func (p *exprParser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("parse error at position %d in %q: %s", tok.pos, p.source, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.match("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.match("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+}
+
+// comparisonOperators are evaluated by compareValues, so expressions and
+// lhs/operator/rhs conditions share the same semantics.
+var comparisonOperators = []string{"==", "!=", ">=", "<=", ">", "<", "in", "notIn", "contains", "startsWith", "endsWith", "matches", "between", "before", "after", "olderThan", "newerThan"}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseCoalesce()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.match(comparisonOperators...)
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseCoalesce()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); (tok.kind == tokenOperator || tok.kind == tokenIdent) && slices.Contains(comparisonOperators, tok.text) {
+		return nil, p.errorf(tok, "comparisons cannot be chained, use && instead")
+	}
+	compare := &compareNode{op: op, left: left, right: right}
+	if literal, ok := right.(*literalNode); ok && op == "matches" {
+		if pattern, ok := literal.value.(string); ok {
+			if compare.pattern, err = compilePattern(pattern); err != nil {
+				return nil, p.errorf(p.tokens[p.pos-1], "%v", err)
+			}
+		}
+	}
+	return compare, nil
+}
+
+// parseCoalesce parses a ?? b, which binds tighter than comparisons so that
+// age ?? 0 >= 18 compares the coalesced value.
+func (p *exprParser) parseCoalesce() (exprNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.match("??"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = &coalesceNode{left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.match("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.match("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if op, ok := p.match("!", "not", "-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "not" {
+			op = "!"
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber, tokenString:
+		return &literalNode{value: tok.value}, nil
+
+	case tokenVariable:
+		return &variableNode{path: tok.text}, nil
+
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null", "nil":
+			return &literalNode{value: nil}, nil
+		}
+		if _, ok := p.match("("); ok {
+			return p.parseCall(tok)
+		}
+		return &variableNode{path: tok.text}, nil
+
+	case tokenOperator:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+
+	default:
+		return nil, p.errorf(tok, "expression ended unexpectedly")
+	}
+}
+
+func (p *exprParser) parseCall(name token) (exprNode, error) {
+	if name.text == "exists" {
+		return p.parseExists(name)
+	}
+
+	fn, ok := expressionFunctions[name.text]
+	if !ok {
+		return nil, p.errorf(name, "unknown function %s", name.text)
+	}
+	args, err := p.parseList(")")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, p.errorf(name, "function %s expects %s, got %d", name.text, describeArity(fn), len(args))
+	}
+	return &callNode{name: name.text, fn: fn, args: args}, nil
+}
+
+// parseExists parses exists(path), which checks for a variable without
+// failing when it is missing.
+func (p *exprParser) parseExists(name token) (exprNode, error) {
+	args, err := p.parseList(")")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 1 {
+		return nil, p.errorf(name, "function exists expects 1 argument, got %d", len(args))
+	}
+	variable, ok := args[0].(*variableNode)
+	if !ok {
+		return nil, p.errorf(name, "function exists expects a variable")
+	}
+	return &existsNode{path: variable.path}, nil
+}
+
+// parseList parses comma separated expressions up to the closing token.
+func (p *exprParser) parseList(closing string) ([]exprNode, error) {
+	var items []exprNode
+	if _, ok := p.match(closing); ok {
+		return items, nil
+	}
+	for {
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if _, ok := p.match(","); ok {
+			continue
+		}
+		if err := p.expect(closing); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+}
+
+// --- Evaluation ---
+
+type exprNode interface {
+	eval(env *evalEnv) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(env *evalEnv) (interface{}, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	path string
+}
+
+func (n *variableNode) eval(env *evalEnv) (interface{}, error) {
+	value, exists := lookupVariable(n.path, env.ctx)
+	if !exists {
+		if env.nullMissing {
+			return nil, nil
+		}
+		return nil, &MissingVariableError{Name: n.path}
+	}
+	return value, nil
+}
+
+type existsNode struct {
+	path string
+}
+
+func (n *existsNode) eval(env *evalEnv) (interface{}, error) {
+	value, exists := lookupVariable(n.path, env.ctx)
+	return exists && normalizeValue(value) != nil, nil
+}
+
+// coalesceNode evaluates to left unless it is null or missing, then to right.
+type coalesceNode struct {
+	left, right exprNode
+}
+
+func (n *coalesceNode) eval(env *evalEnv) (interface{}, error) {
+	value, err := n.left.eval(env)
+	if err != nil {
+		var missing *MissingVariableError
+		if !errors.As(err, &missing) {
+			return nil, err
+		}
+	} else if normalizeValue(value) != nil {
+		return value, nil
+	}
+	return n.right.eval(env)
+}
+
+type listNode struct {
+	items []exprNode
+}
+
+func (n *listNode) eval(env *evalEnv) (interface{}, error) {
+	values := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		value, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+func (n *unaryNode) eval(env *evalEnv) (interface{}, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, err := toBool(value, "!")
+		if err != nil {
+			return nil, err
+		}
+		return !b, nil
+	}
+	number, ok := toFloat64(value)
+	if !ok {
+		return nil, fmt.Errorf("operator - expects a number, got %s", describeType(value))
+	}
+	return -number, nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *logicalNode) eval(env *evalEnv) (interface{}, error) {
+	leftValue, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	left, err := toBool(leftValue, n.op)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit so the right side may rely on the left, e.g. x != null && x > 1
+	if (n.op == "&&" && !left) || (n.op == "||" && left) {
+		return left, nil
+	}
+	rightValue, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return toBool(rightValue, n.op)
+}
+
+type compareNode struct {
+	op          string
+	left, right exprNode
+	// pattern is the compiled right side of matches against a string literal
+	pattern *regexp.Regexp
+}
+
+func (n *compareNode) eval(env *evalEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.pattern != nil {
+		return compareValues(left, n.pattern, n.op, env.coercion)
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return compareValues(left, right, n.op, env.coercion)
+}
+
+type arithmeticNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *arithmeticNode) eval(env *evalEnv) (interface{}, error) {
+	leftValue, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	rightValue, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	_, leftIsString := leftValue.(string)
+	_, rightIsString := rightValue.(string)
+	if n.op == "+" && (leftIsString || rightIsString) {
+		return formatTemplateValue(leftValue) + formatTemplateValue(rightValue), nil
+	}
+
+	left, leftOk := toFloat64(leftValue)
+	right, rightOk := toFloat64(rightValue)
+	if !leftOk || !rightOk {
+		return nil, fmt.Errorf("operator %s expects numbers, got %s and %s", n.op, describeType(leftValue), describeType(rightValue))
+	}
+
+	switch n.op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return left / right, nil
+	default:
+		if right == 0 {
+			return nil, fmt.Errorf("modulo by zero")
+		}
+		return math.Mod(left, right), nil
+	}
+}
+
+type callNode struct {
+	name string
+	fn   expressionFunction
+	args []exprNode
+}
+
+func (n *callNode) eval(env *evalEnv) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	var result interface{}
+	var err error
+	if operator, ok := operatorFunctions[n.name]; ok {
+		result, err = compareValues(args[0], args[1], operator, env.coercion)
+	} else {
+		result, err = n.fn.call(args)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", n.name, err)
+	}
+	return result, nil
+}
+
+func toBool(value interface{}, op string) (bool, error) {
+	switch val := value.(type) {
+	case bool:
+		return val, nil
+	case nil:
+		return false, nil
+	default:
+		return false, fmt.Errorf("operator %s expects booleans, got %s", op, describeType(value))
+	}
+}
+
+func describeType(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,197 @@
+package nodes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// expressionFunction is a built-in function callable from expressions.
+// maxArgs is -1 for variadic functions.
+type expressionFunction struct {
+	minArgs int
+	maxArgs int
+	call    func(args []interface{}) (interface{}, error)
+}
+
+var expressionFunctions = map[string]expressionFunction{
+	"len":         {1, 1, fnLen},
+	"lower":       {1, 1, stringFunction(strings.ToLower)},
+	"upper":       {1, 1, stringFunction(strings.ToUpper)},
+	"trim":        {1, 1, stringFunction(strings.TrimSpace)},
+	"contains":    {2, 2, nil}, // operatorFunctions
+	"startsWith":  {2, 2, nil},
+	"endsWith":    {2, 2, nil},
+	"matches":     {2, 2, nil},
+	"isEmpty":     {1, 1, fnIsEmpty},
+	"concat":      {1, -1, fnConcat},
+	"substring":   {2, 3, fnSubstring},
+	"replace":     {3, 3, fnReplace},
+	"string":      {1, 1, fnString},
+	"number":      {1, 1, fnNumber},
+	"abs":         {1, 1, numberFunction(math.Abs)},
+	"round":       {1, 1, numberFunction(math.Round)},
+	"floor":       {1, 1, numberFunction(math.Floor)},
+	"ceil":        {1, 1, numberFunction(math.Ceil)},
+	"now":         {0, 0, fnNow},
+	"toDate":      {1, 1, fnToDate},
+	"addDuration": {2, 2, fnAddDuration},
+}
+
+// operatorFunctions expose condition operators as two-argument functions.
+// They are evaluated by compareValues so they follow the coercion policy.
+var operatorFunctions = map[string]string{
+	"contains":   "contains",
+	"startsWith": "startsWith",
+	"endsWith":   "endsWith",
+	"matches":    "matches",
+}
+
+func describeArity(fn expressionFunction) string {
+	switch {
+	case fn.maxArgs < 0:
+		return fmt.Sprintf("at least %d arguments", fn.minArgs)
+	case fn.minArgs == fn.maxArgs && fn.minArgs == 1:
+		return "1 argument"
+	case fn.minArgs == fn.maxArgs:
+		return fmt.Sprintf("%d arguments", fn.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", fn.minArgs, fn.maxArgs)
+	}
+}
+
+func stringArg(args []interface{}, i int) (string, error) {
+	str, ok := args[i].(string)
+	if !ok {
+		return "", fmt.Errorf("argument %d must be a string, got %s", i+1, describeType(args[i]))
+	}
+	return str, nil
+}
+
+func numberArg(args []interface{}, i int) (float64, error) {
+	number, ok := toFloat64(args[i])
+	if !ok {
+		return 0, fmt.Errorf("argument %d must be a number, got %s", i+1, describeType(args[i]))
+	}
+	return number, nil
+}
+
+func stringFunction(fn func(string) string) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		str, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(str), nil
+	}
+}
+
+func numberFunction(fn func(float64) float64) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		number, err := numberArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(number), nil
+	}
+}
+
+// fnLen returns the length of a string (in characters), array or document.
+func fnLen(args []interface{}) (interface{}, error) {
+	if length, ok := valueLength(args[0]); ok {
+		return float64(length), nil
+	}
+	return nil, fmt.Errorf("argument must be a string, array or object, got %s", describeType(args[0]))
+}
+
+func fnIsEmpty(args []interface{}) (interface{}, error) {
+	return isEmptyValue(args[0]), nil
+}
+
+func fnConcat(args []interface{}) (interface{}, error) {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString(formatTemplateValue(arg))
+	}
+	return b.String(), nil
+}
+
+func fnSubstring(args []interface{}) (interface{}, error) {
+	str, err := stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(str)
+	start, err := numberArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	end := float64(len(runes))
+	if len(args) == 3 {
+		if end, err = numberArg(args, 2); err != nil {
+			return nil, err
+		}
+	}
+	from := max(0, min(int(start), len(runes)))
+	to := max(from, min(int(end), len(runes)))
+	return string(runes[from:to]), nil
+}
+
+func fnReplace(args []interface{}) (interface{}, error) {
+	str, err := stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	old, err := stringArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := stringArg(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(str, old, replacement), nil
+}
+
+func fnString(args []interface{}) (interface{}, error) {
+	return formatTemplateValue(args[0]), nil
+}
+
+func fnNumber(args []interface{}) (interface{}, error) {
+	if number, ok := toFloat64(args[0]); ok {
+		return number, nil
+	}
+	if str, ok := args[0].(string); ok {
+		if number, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err == nil {
+			return number, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %s to a number", describeType(args[0]))
+}
+
+func fnNow(args []interface{}) (interface{}, error) {
+	return clock().UTC(), nil
+}
+
+// fnToDate converts an RFC3339 string or BSON date to a date value, which is
+// stored as a BSON date when inserted into MongoDB.
+func fnToDate(args []interface{}) (interface{}, error) {
+	t, ok := toTime(args[0])
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %v to a date, expected an RFC3339 string", args[0])
+	}
+	return t, nil
+}
+
+func fnAddDuration(args []interface{}) (interface{}, error) {
+	t, ok := toTime(args[0])
+	if !ok {
+		return nil, fmt.Errorf("argument 1 must be a date, got %s", describeType(args[0]))
+	}
+	d, ok := toDuration(args[1])
+	if !ok {
+		return nil, fmt.Errorf("argument 2 must be a duration such as \"7d\" or \"-12h\", got %v", args[1])
+	}
+	return t.Add(d), nil
+}
@@ -0,0 +1,220 @@
+package nodes
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+func evaluate(t *testing.T, source string, ctx map[string]interface{}) (interface{}, error) {
+	t.Helper()
+	expression, err := ParseExpression(source)
+	if err != nil {
+		t.Fatalf("ParseExpression(%q): %v", source, err)
+	}
+	return expression.Evaluate(ctx)
+}
+
+func TestExpressionEvaluate(t *testing.T) {
+	ctx := map[string]interface{}{
+		"age":     20.0,
+		"country": "USA",
+		"vip":     false,
+		"name":    "Ada Lovelace",
+		"tags":    []interface{}{"admin", "beta"},
+		"user": map[string]interface{}{
+			"address": map[string]interface{}{"city": "Paris"},
+		},
+		"nodes": map[string]interface{}{
+			"register-user": map[string]interface{}{"insertedID": "652f"},
+		},
+	}
+
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		// Precedence and parentheses
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 4 - 3", 3.0},
+		{"2 * 3 % 4", 2.0},
+		{"-2 * 3", -6.0},
+		{"1 + 2 == 3", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"!vip && age >= 18", true},
+		{"!(age >= 18)", false},
+		{"age >= 18 && (country == \"UK\" || vip)", false},
+		{"age >= 18 and not vip or country == \"UK\"", true},
+		// Arithmetic
+		{"age / 8", 2.5},
+		{"age % 6", 2.0},
+		{"age - 25", -5.0},
+		{"\"id-\" + 7", "id-7"},
+		// Comparisons, variables and literals
+		{"user.address.city == \"Paris\"", true},
+		{"tags[1] == \"beta\"", true},
+		{"{{nodes.register-user.insertedID}} == \"652f\"", true},
+		{"age in [18, 20]", true},
+		{"age between [21, 30]", false},
+		{"tags contains \"admin\"", true},
+		{"name startsWith \"Ada\"", true},
+		{"name matches \"^Ada [A-Z]\"", true},
+		{"missing ?? \"anonymous\"", "anonymous"},
+		{"country ?? \"anonymous\"", "USA"},
+		{"exists(user.address.city)", true},
+		{"exists(user.phone)", false},
+		// String functions
+		{"lower(name)", "ada lovelace"},
+		{"upper(country)", "USA"},
+		{"trim(\"  padded \")", "padded"},
+		{"len(name)", 12.0},
+		{"len(tags)", 2.0},
+		{"concat(name, \" (\", age, \")\")", "Ada Lovelace (20)"},
+		{"substring(name, 4)", "Lovelace"},
+		{"substring(name, 0, 3)", "Ada"},
+		{"substring(name, 10, 100)", "ce"},
+		{"replace(name, \" \", \"_\")", "Ada_Lovelace"},
+		{"contains(name, \"Love\")", true},
+		{"startsWith(lower(name), \"ada\")", true},
+		{"endsWith(name, \"ace\")", true},
+		{"matches(country, \"^U\")", true},
+		{"isEmpty(\"\")", true},
+		{"string(age)", "20"},
+		{"number(\" 42 \")", 42.0},
+		{"abs(-3) + round(2.5) + floor(1.9) + ceil(1.1)", 9.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := evaluate(t, tt.source, ctx)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExpressionShortCircuit checks that the right side of && and || is not
+// evaluated when the left side decides the result, so it may rely on it.
+func TestExpressionShortCircuit(t *testing.T) {
+	ctx := map[string]interface{}{"count": 0.0}
+
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"false && missing > 1", false},
+		{"true || missing > 1", true},
+		{"count != 0 && 10 / count > 1", false},
+		{"count == 0 || 10 / count > 1", true},
+		{"exists(user) && user.name == \"Ada\"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			expression, err := ParseExpression(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := expression.EvaluateBool(ctx); err != nil || got != tt.want {
+				t.Errorf("EvaluateBool() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := evaluate(t, "true && missing > 1", ctx); err == nil {
+		t.Error("true && missing > 1 should evaluate its right side and fail")
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	ctx := map[string]interface{}{"age": 20.0, "name": "Ada"}
+
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{"1 / 0", "division by zero"},
+		{"age % 0", "modulo by zero"},
+		{"name - 1", "operator - expects numbers, got string and float64"},
+		{"-name", "operator - expects a number, got string"},
+		{"age && true", "operator && expects booleans, got float64"},
+		{"lower(age)", "lower(): argument 1 must be a string, got float64"},
+		{"number(name)", "number(): cannot convert string to a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := evaluate(t, tt.source, ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Evaluate() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestExpressionMissingVariables(t *testing.T) {
+	expression, err := ParseExpression("user.age >= 18")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := map[string]interface{}{"user": map[string]interface{}{}}
+
+	_, err = expression.EvaluateBool(ctx)
+	var missing *MissingVariableError
+	if !errors.As(err, &missing) || missing.Name != "user.age" {
+		t.Errorf("EvaluateBool() error = %v, want missing user.age", err)
+	}
+
+	got, err := expression.WithMissingAsNull().EvaluateBool(ctx)
+	if err != nil || got {
+		t.Errorf("with missing as null: EvaluateBool() = %v, %v, want false", got, err)
+	}
+
+	if got := expression.Variables(); !reflect.DeepEqual(got, []string{"user.age"}) {
+		t.Errorf("Variables() = %v", got)
+	}
+}
+
+func TestExpressionParseErrors(t *testing.T) {
+	sources := []string{
+		"",
+		"age >=",
+		"(age > 18",
+		"age > 18)",
+		"age > 18 > 10",
+		"age >> 1",
+		"\"unterminated",
+		"[1, 2",
+		"unknown(1)",
+		"lower()",
+		"substring(\"a\", 1, 2, 3)",
+		"exists(1)",
+		"name matches \"[\"",
+		"age 18",
+	}
+	for _, source := range sources {
+		t.Run(source, func(t *testing.T) {
+			if _, err := ParseExpression(source); err == nil {
+				t.Errorf("ParseExpression(%q) should fail", source)
+			}
+		})
+	}
+}
+
+func TestConditionExpressionParseError(t *testing.T) {
+	_, err := NewConditionNode(workflow.NodeDefinition{
+		ID:     "check-age",
+		Type:   "condition",
+		Config: map[string]interface{}{"expression": "age >= 18 &&"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Errorf("NewConditionNode() error = %v, want parse error", err)
+	}
+}
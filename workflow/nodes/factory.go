@@ -16,6 +16,30 @@ func CreateNode(def workflow.NodeDefinition) (workflow.Node, error) {
 		return NewMongoDBInsertNode(def)
 	case "mongodb_find":
 		return NewMongoDBFindNode(def)
+	case "mongodb_update":
+		return NewMongoDBUpdateNode(def)
+	case "mongodb_delete":
+		return NewMongoDBDeleteNode(def)
+	case "mongodb_aggregate":
+		return NewMongoDBAggregateNode(def)
+	case "mongodb_bulk_write":
+		return NewMongoDBBulkWriteNode(def)
+	case "mongodb_transaction":
+		return NewMongoDBTransactionNode(def)
+	case "mongodb_count":
+		return NewMongoDBCountNode(def)
+	case "mongodb_distinct":
+		return NewMongoDBDistinctNode(def)
+	case "join":
+		return NewJoinNode(def)
+	case "switch":
+		return NewSwitchNode(def)
+	case "decision_table":
+		return NewDecisionTableNode(def)
+	case "ruleset":
+		return NewRulesetNode(def)
+	case "scoring":
+		return NewScoringNode(def)
 
 	default:
 		return nil, fmt.Errorf("unknown node type: %s", def.Type)
@@ -0,0 +1,116 @@
+package nodes
+
+import (
+	"fmt"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+// JoinNode waits for its incoming parallel branches and runs once, exposing
+// the data written by each branch under OutputKey keyed by source node ID and
+// merging the branches into the shared context according to Merge.
+//
+// Modes:
+//   - "all" (default): fire when every incoming branch has arrived
+//   - "any": fire on the first branch, later branches are ignored
+//   - "n": fire once Count branches have arrived
+type JoinNode struct {
+	ID        string
+	Mode      string
+	Count     int
+	Merge     workflow.MergeStrategy
+	OutputKey string
+}
+
+func NewJoinNode(def workflow.NodeDefinition) (*JoinNode, error) {
+	mode := "all"
+	if modeValue, exists := def.Config["mode"]; exists {
+		modeStr, ok := modeValue.(string)
+		if !ok {
+			return nil, fmt.Errorf("mode must be a string")
+		}
+		mode = modeStr
+	}
+
+	count := 0
+	switch mode {
+	case "all", "any":
+	case "n":
+		countFloat, ok := def.Config["count"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("count must be a number when mode is \"n\"")
+		}
+		count = int(countFloat)
+		if count < 1 {
+			return nil, fmt.Errorf("count must be at least 1")
+		}
+	default:
+		return nil, fmt.Errorf("unknown join mode: %s", mode)
+	}
+
+	merge := workflow.MergeLastWriterWins
+	if mergeValue, exists := def.Config["merge"]; exists {
+		mergeStr, ok := mergeValue.(string)
+		if !ok {
+			return nil, fmt.Errorf("merge must be a string")
+		}
+		strategy, err := workflow.ParseMergeStrategy(mergeStr)
+		if err != nil {
+			return nil, err
+		}
+		merge = strategy
+	}
+
+	outputKey := "branches" // default
+	if keyValue, exists := def.Config["outputKey"]; exists {
+		if key, ok := keyValue.(string); ok {
+			outputKey = key
+		}
+	}
+
+	return &JoinNode{
+		ID:        def.ID,
+		Mode:      mode,
+		Count:     count,
+		Merge:     merge,
+		OutputKey: outputKey,
+	}, nil
+}
+
+func (n *JoinNode) Ready(arrived, expected int) bool {
+	switch n.Mode {
+	case "any":
+		return arrived >= 1
+	case "n":
+		return arrived >= n.Count
+	default:
+		return arrived >= expected
+	}
+}
+
+func (n *JoinNode) Join(ctx map[string]interface{}, branches []workflow.Branch) (workflow.NodeResult, error) {
+	merged, deleted, err := workflow.MergeBranches(branches, n.Merge)
+	if err != nil {
+		return workflow.NodeResult{}, err
+	}
+
+	outputs := make(map[string]interface{}, len(branches))
+	for _, branch := range branches {
+		outputs[branch.From] = branch.Data
+	}
+	merged[n.OutputKey] = outputs
+
+	return workflow.NodeResult{
+		Output:  "default",
+		Data:    merged,
+		Deleted: deleted,
+	}, nil
+}
+
+func (n *JoinNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	return n.Join(ctx, nil)
+}
+
+func (n *JoinNode) Outputs() []string {
+	return []string{"default"}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -32,44 +31,3 @@ func InitMongoDB(connectionString string) error {
 	log.Println("✅ Successfully connected to MongoDB")
 	return nil
 }
-
-func ResolveMapValues(data map[string]interface{}, ctx map[string]interface{}) (map[string]interface{}, error) {
-	result := make(map[string]interface{})
-
-	for key, value := range data {
-		// Check if value is a string
-		strValue, isString := value.(string)
-
-		if isString {
-			// Check if it's a template variable
-			if strings.HasPrefix(strValue, "{{") && strings.HasSuffix(strValue, "}}") {
-				// Extract variable name
-				varName := strings.TrimPrefix(strValue, "{{")
-				varName = strings.TrimSuffix(varName, "}}")
-				varName = strings.TrimSpace(varName)
-
-				// Get value from context
-				ctxValue, exist := ctx[varName]
-				if !exist {
-					return nil, fmt.Errorf("variable %s not found in context", varName)
-				}
-				result[key] = ctxValue
-			} else {
-				// It's a regular string, keep as-is
-				result[key] = strValue
-			}
-		} else if nestedMap, isMap := value.(map[string]interface{}); isMap {
-			// It's a nested map, resolve recursively
-			resolvedNested, err := ResolveMapValues(nestedMap, ctx)
-			if err != nil {
-				return nil, err
-			}
-			result[key] = resolvedNested
-		} else {
-			// It's a number, boolean, etc - keep as-is
-			result[key] = value
-		}
-	}
-
-	return result, nil
-}
@@ -0,0 +1,166 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoDBAggregateNode struct {
+	ID           string
+	Database     string
+	Collection   string
+	Resolver     Resolver
+	Pipeline     []interface{}
+	AllowDiskUse bool
+	MaxTime      time.Duration // 0 means no limit
+	OutputKey    string
+}
+
+func NewMongoDBAggregateNode(def workflow.NodeDefinition) (*MongoDBAggregateNode, error) {
+	// Extract and validate database
+	database, ok := def.Config["database"].(string)
+	if !ok {
+		return nil, fmt.Errorf("database must be a string")
+	}
+
+	// Extract and validate collection
+	collection, ok := def.Config["collection"].(string)
+	if !ok {
+		return nil, fmt.Errorf("collection must be a string")
+	}
+
+	// Extract and validate pipeline
+	pipeline, ok := def.Config["pipeline"].([]interface{})
+	if !ok || len(pipeline) == 0 {
+		return nil, fmt.Errorf("pipeline must be a non-empty array of stages")
+	}
+	for i, stageValue := range pipeline {
+		if err := validatePipelineStage(stageValue); err != nil {
+			return nil, fmt.Errorf("pipeline stage %d: %w", i, err)
+		}
+	}
+
+	allowDiskUse := false
+	if allowValue, exists := def.Config["allowDiskUse"]; exists {
+		if allowDiskUse, ok = allowValue.(bool); !ok {
+			return nil, fmt.Errorf("allowDiskUse must be a boolean")
+		}
+	}
+
+	maxTime, err := maxTimeFromConfig(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	outputKey := "results" // default
+	if keyValue, exists := def.Config["outputKey"]; exists {
+		if key, ok := keyValue.(string); ok {
+			outputKey = key
+		}
+	}
+
+	resolver, err := NewResolver(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoDBAggregateNode{
+		ID:           def.ID,
+		Database:     database,
+		Collection:   collection,
+		Resolver:     resolver,
+		Pipeline:     pipeline,
+		AllowDiskUse: allowDiskUse,
+		MaxTime:      maxTime,
+		OutputKey:    outputKey,
+	}, nil
+}
+
+// validatePipelineStage checks that a stage is an object with a single
+// stage operator such as $match or $group.
+func validatePipelineStage(value interface{}) error {
+	stage, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("must be an object")
+	}
+	if len(stage) != 1 {
+		return fmt.Errorf("must have exactly one stage operator, got %d keys", len(stage))
+	}
+	for key := range stage {
+		if !strings.HasPrefix(key, "$") {
+			return fmt.Errorf("unknown stage %s, stage operators start with $", key)
+		}
+	}
+	return nil
+}
+
+// maxTimeFromConfig reads the optional "maxTimeMS" setting.
+func maxTimeFromConfig(config map[string]interface{}) (time.Duration, error) {
+	maxTimeValue, exists := config["maxTimeMS"]
+	if !exists {
+		return 0, nil
+	}
+	maxTimeMS, ok := maxTimeValue.(float64)
+	if !ok || maxTimeMS <= 0 {
+		return 0, fmt.Errorf("maxTimeMS must be a positive number")
+	}
+	return time.Duration(maxTimeMS * float64(time.Millisecond)), nil
+}
+
+func (n *MongoDBAggregateNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	return n.ExecuteContext(context.Background(), ctx)
+}
+
+func (n *MongoDBAggregateNode) ExecuteContext(goCtx context.Context, ctx map[string]interface{}) (workflow.NodeResult, error) {
+	resolvedPipeline, err := n.Resolver.resolveArray(n.Pipeline, ctx)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to resolve pipeline: %w", err)
+	}
+
+	log.Printf("Aggregating documents in %s.%s with pipeline: %v", n.Database, n.Collection, resolvedPipeline)
+
+	opts := options.Aggregate().SetAllowDiskUse(n.AllowDiskUse)
+	if n.MaxTime > 0 {
+		opts.SetMaxTime(n.MaxTime)
+	}
+
+	collection := MongoClient.Database(n.Database).Collection(n.Collection)
+	cursor, err := collection.Aggregate(goCtx, resolvedPipeline, opts)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to aggregate documents: %w", err)
+	}
+
+	defer cursor.Close(goCtx)
+
+	results := make([]map[string]interface{}, 0)
+	for cursor.Next(goCtx) {
+		var result map[string]interface{}
+		if err := cursor.Decode(&result); err != nil {
+			return workflow.NodeResult{}, fmt.Errorf("failed to decode document: %w", err)
+		}
+		results = append(results, result)
+	}
+	if err := cursor.Err(); err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to aggregate documents: %w", err)
+	}
+
+	log.Printf("Aggregated %d documents in %s.%s", len(results), n.Database, n.Collection)
+
+	return workflow.NodeResult{
+		Output: "default",
+		Data: map[string]interface{}{
+			n.OutputKey:           results,
+			n.OutputKey + "Count": len(results),
+		},
+	}, nil
+}
+
+func (n *MongoDBAggregateNode) Outputs() []string {
+	return []string{"default"}
+}
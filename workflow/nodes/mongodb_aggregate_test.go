@@ -0,0 +1,103 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMongoDBAggregateBuildChecks(t *testing.T) {
+	match := map[string]interface{}{"$match": map[string]interface{}{"status": "{{status}}"}}
+	group := map[string]interface{}{"$group": map[string]interface{}{"_id": "$country", "total": map[string]interface{}{"$sum": 1.0}}}
+
+	tests := []buildCheck{
+		{name: "valid pipeline", config: map[string]interface{}{"pipeline": []interface{}{match, group}}},
+		{name: "missing pipeline", config: map[string]interface{}{}, err: "pipeline must be a non-empty array of stages"},
+		{name: "empty pipeline", config: map[string]interface{}{"pipeline": []interface{}{}}, err: "pipeline must be a non-empty array of stages"},
+		{name: "pipeline not an array", config: map[string]interface{}{"pipeline": match}, err: "pipeline must be a non-empty array of stages"},
+		{name: "stage not an object", config: map[string]interface{}{"pipeline": []interface{}{match, "$group"}}, err: "pipeline stage 1: must be an object"},
+		{
+			name:   "stage with two operators",
+			config: map[string]interface{}{"pipeline": []interface{}{map[string]interface{}{"$match": map[string]interface{}{}, "$limit": 5.0}}},
+			err:    "pipeline stage 0: must have exactly one stage operator, got 2 keys",
+		},
+		{name: "empty stage", config: map[string]interface{}{"pipeline": []interface{}{map[string]interface{}{}}}, err: "pipeline stage 0: must have exactly one stage operator, got 0 keys"},
+		{
+			name:   "stage without $",
+			config: map[string]interface{}{"pipeline": []interface{}{match, map[string]interface{}{"limit": 5.0}}},
+			err:    "pipeline stage 1: unknown stage limit, stage operators start with $",
+		},
+		{name: "allowDiskUse not a boolean", config: map[string]interface{}{"pipeline": []interface{}{match}, "allowDiskUse": 1.0}, err: "allowDiskUse must be a boolean"},
+		{name: "non-positive maxTimeMS", config: map[string]interface{}{"pipeline": []interface{}{match}, "maxTimeMS": 0.0}, err: "maxTimeMS must be a positive number"},
+	}
+
+	runBuildChecks(t, "mongodb_aggregate", NewMongoDBAggregateNode, map[string]interface{}{"database": "db", "collection": "orders"}, tests)
+}
+
+func TestMongoDBAggregateExecute(t *testing.T) {
+	mt := newMockMongo(t)
+	mt.Run("results", func(mt *mtest.T) {
+		useMockClient(mt)
+		node, err := NewMongoDBAggregateNode(workflow.NodeDefinition{ID: "aggregate", Type: "mongodb_aggregate", Config: map[string]interface{}{
+			"database":   "db",
+			"collection": "orders",
+			"outputKey":  "totals",
+			"pipeline": []interface{}{
+				map[string]interface{}{"$match": map[string]interface{}{"status": "{{status}}"}},
+				map[string]interface{}{"$group": map[string]interface{}{"_id": "$country", "total": map[string]interface{}{"$sum": 1.0}}},
+			},
+		}})
+		if err != nil {
+			mt.Fatal(err)
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.orders", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "FR"}, {Key: "total", Value: 2}},
+			bson.D{{Key: "_id", Value: "US"}, {Key: "total", Value: 5}},
+		))
+
+		result, err := node.Execute(map[string]interface{}{"status": "paid"})
+		if err != nil {
+			mt.Fatalf("Execute() error = %v", err)
+		}
+		want := map[string]interface{}{
+			"totals": []map[string]interface{}{
+				{"_id": "FR", "total": int32(2)},
+				{"_id": "US", "total": int32(5)},
+			},
+			"totalsCount": 2,
+		}
+		if !reflect.DeepEqual(result.Data, want) {
+			mt.Errorf("Execute() = %v, want %v", result.Data, want)
+		}
+
+		match := sentCommand(mt)["pipeline"].(bson.A)[0]
+		if want := (bson.M{"$match": bson.M{"status": "paid"}}); !reflect.DeepEqual(match, want) {
+			mt.Errorf("$match stage = %v, want %v", match, want)
+		}
+	})
+
+	mt.Run("no results", func(mt *mtest.T) {
+		useMockClient(mt)
+		node, err := NewMongoDBAggregateNode(workflow.NodeDefinition{ID: "aggregate", Type: "mongodb_aggregate", Config: map[string]interface{}{
+			"database":   "db",
+			"collection": "orders",
+			"pipeline":   []interface{}{map[string]interface{}{"$match": map[string]interface{}{"status": "refunded"}}},
+		}})
+		if err != nil {
+			mt.Fatal(err)
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.orders", mtest.FirstBatch))
+
+		result, err := node.Execute(map[string]interface{}{})
+		if err != nil {
+			mt.Fatalf("Execute() error = %v", err)
+		}
+		want := map[string]interface{}{"results": []map[string]interface{}{}, "resultsCount": 0}
+		if !reflect.DeepEqual(result.Data, want) {
+			mt.Errorf("Execute() = %v, want %v", result.Data, want)
+		}
+	})
+}
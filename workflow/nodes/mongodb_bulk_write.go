@@ -0,0 +1,350 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBBulkWriteNode writes one operation per item of an array in a single
+// bulk write. Operation is a template resolved once per item, with the item
+// available as {{item}} and its position as {{itemIndex}}.
+type MongoDBBulkWriteNode struct {
+	ID               string
+	Database         string
+	Collection       string
+	Resolver         Resolver
+	Items            interface{} // template resolving to an array, or an array
+	Operation        map[string]interface{}
+	Ordered          bool
+	AllowEmptyFilter bool
+	ItemKey          string
+	OutputKey        string
+}
+
+func NewMongoDBBulkWriteNode(def workflow.NodeDefinition) (*MongoDBBulkWriteNode, error) {
+	// Extract and validate database
+	database, ok := def.Config["database"].(string)
+	if !ok {
+		return nil, fmt.Errorf("database must be a string")
+	}
+
+	// Extract and validate collection
+	collection, ok := def.Config["collection"].(string)
+	if !ok {
+		return nil, fmt.Errorf("collection must be a string")
+	}
+
+	items, exists := def.Config["items"]
+	if !exists {
+		return nil, fmt.Errorf("items is required")
+	}
+	switch items.(type) {
+	case string, []interface{}:
+	default:
+		return nil, fmt.Errorf("items must be a template such as \"{{users}}\" or an array")
+	}
+
+	allowEmptyFilter := false
+	if allowValue, exists := def.Config["allowEmptyFilter"]; exists {
+		if allowEmptyFilter, ok = allowValue.(bool); !ok {
+			return nil, fmt.Errorf("allowEmptyFilter must be a boolean")
+		}
+	}
+
+	operation, ok := def.Config["operation"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("operation must be an object")
+	}
+	if err := validateBulkOperation(operation, allowEmptyFilter); err != nil {
+		return nil, err
+	}
+
+	ordered := true // default, like MongoDB
+	if orderedValue, exists := def.Config["ordered"]; exists {
+		if ordered, ok = orderedValue.(bool); !ok {
+			return nil, fmt.Errorf("ordered must be a boolean")
+		}
+	}
+
+	itemKey := "item" // default
+	if keyValue, exists := def.Config["itemKey"]; exists {
+		key, ok := keyValue.(string)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("itemKey must be a non-empty string")
+		}
+		itemKey = key
+	}
+
+	outputKey := "bulkWrite" // default
+	if keyValue, exists := def.Config["outputKey"]; exists {
+		if key, ok := keyValue.(string); ok {
+			outputKey = key
+		}
+	}
+
+	resolver, err := NewResolver(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoDBBulkWriteNode{
+		ID:               def.ID,
+		Database:         database,
+		Collection:       collection,
+		Resolver:         resolver,
+		Items:            items,
+		Operation:        operation,
+		Ordered:          ordered,
+		AllowEmptyFilter: allowEmptyFilter,
+		ItemKey:          itemKey,
+		OutputKey:        outputKey,
+	}, nil
+}
+
+// validateBulkOperation checks what can be checked before the items are
+// known. String fields are templates, and a templated type can pick any
+// operation, so those are only checked once resolved for each item.
+func validateBulkOperation(operation map[string]interface{}, allowEmptyFilter bool) error {
+	operationType, ok := operation["type"].(string)
+	if !ok {
+		return fmt.Errorf("operation type must be a string")
+	}
+	for key := range operation {
+		switch key {
+		case "type", "document", "filter", "update":
+		default:
+			return fmt.Errorf("operation: unknown field %s", key)
+		}
+	}
+	if strings.Contains(operationType, "{{") {
+		return nil
+	}
+
+	fields, err := bulkOperationFields(operationType)
+	if err != nil {
+		return err
+	}
+	for key := range operation {
+		if key != "type" && !fields[key] {
+			return fmt.Errorf("operation %s does not take %s", operationType, key)
+		}
+	}
+
+	templated := make(map[string]bool)
+	for key, value := range operation {
+		if _, isString := value.(string); isString && key != "type" {
+			templated[key] = true
+		}
+	}
+	_, err = bulkWriteModel(operationType, operation, allowEmptyFilter, templated)
+	return err
+}
+
+// bulkOperationFields returns the fields an operation type takes besides type.
+func bulkOperationFields(operationType string) (map[string]bool, error) {
+	switch operationType {
+	case "insert":
+		return map[string]bool{"document": true}, nil
+	case "update", "upsert":
+		return map[string]bool{"filter": true, "update": true}, nil
+	case "delete":
+		return map[string]bool{"filter": true}, nil
+	default:
+		return nil, fmt.Errorf("unknown operation type: %s (use insert, update, upsert or delete)", operationType)
+	}
+}
+
+// bulkWriteModel turns a resolved operation into a write model, ignoring
+// fields the operation type doesn't take. Fields listed in templated are not
+// resolved yet; they are skipped and no model is built.
+func bulkWriteModel(operationType string, operation map[string]interface{}, allowEmptyFilter bool, templated map[string]bool) (mongo.WriteModel, error) {
+	fields, err := bulkOperationFields(operationType)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		if _, exists := operation[key]; !exists {
+			return nil, fmt.Errorf("operation %s requires %s", operationType, key)
+		}
+	}
+	for key := range fields {
+		if templated[key] {
+			return nil, nil
+		}
+	}
+
+	switch operationType {
+	case "insert":
+		document, ok := operation["document"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operation document must be an object")
+		}
+		return mongo.NewInsertOneModel().SetDocument(document), nil
+	case "update", "upsert":
+		filter, ok := operation["filter"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operation filter must be an object")
+		}
+		update, err := validateUpdate(operation["update"])
+		if err != nil {
+			return nil, fmt.Errorf("operation %w", err)
+		}
+		return mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(operationType == "upsert"), nil
+	default:
+		filter, ok := operation["filter"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operation filter must be an object")
+		}
+		// An empty filter deletes an arbitrary document
+		if len(filter) == 0 && !allowEmptyFilter {
+			return nil, fmt.Errorf("operation delete: filter must not be empty unless allowEmptyFilter is true")
+		}
+		return mongo.NewDeleteOneModel().SetFilter(filter), nil
+	}
+}
+
+func (n *MongoDBBulkWriteNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	return n.ExecuteContext(context.Background(), ctx)
+}
+
+func (n *MongoDBBulkWriteNode) ExecuteContext(goCtx context.Context, ctx map[string]interface{}) (workflow.NodeResult, error) {
+	itemsValue, err := n.Resolver.Resolve(n.Items, ctx)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to resolve items: %w", err)
+	}
+	items, ok := toSlice(itemsValue)
+	if !ok {
+		return workflow.NodeResult{}, fmt.Errorf("items must resolve to an array, got %T", itemsValue)
+	}
+
+	// Build one write model per item. Items whose operation doesn't resolve
+	// are reported like write errors; with ordered writes nothing after the
+	// first of them is written, as MongoDB would do for a failed write.
+	itemCtx := make(map[string]interface{}, len(ctx)+2)
+	for key, value := range ctx {
+		itemCtx[key] = value
+	}
+	var models []mongo.WriteModel
+	var modelItems []int
+	itemErrors := []interface{}{}
+	for i, item := range items {
+		itemCtx[n.ItemKey] = item
+		itemCtx[n.ItemKey+"Index"] = i
+
+		model, err := n.itemModel(itemCtx)
+		if err != nil {
+			itemErrors = append(itemErrors, bulkItemError(i, 0, err.Error()))
+			if n.Ordered {
+				break
+			}
+			continue
+		}
+		models = append(models, model)
+		modelItems = append(modelItems, i)
+	}
+
+	summary := map[string]interface{}{
+		"itemCount":     len(items),
+		"insertedCount": int64(0),
+		"matchedCount":  int64(0),
+		"modifiedCount": int64(0),
+		"deletedCount":  int64(0),
+		"upsertedCount": int64(0),
+		"upsertedIds":   map[string]interface{}{},
+	}
+
+	if len(models) > 0 {
+		collection := MongoClient.Database(n.Database).Collection(n.Collection)
+		result, err := collection.BulkWrite(goCtx, models, options.BulkWrite().SetOrdered(n.Ordered))
+
+		var bulkErr mongo.BulkWriteException
+		if err != nil && !(errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil) {
+			return workflow.NodeResult{}, fmt.Errorf("failed to bulk write documents: %w", err)
+		}
+		for _, writeErr := range bulkErr.WriteErrors {
+			itemErrors = append(itemErrors, bulkItemError(modelItems[writeErr.Index], writeErr.Code, writeErr.Message))
+		}
+
+		if result != nil {
+			upsertedIDs := make(map[string]interface{}, len(result.UpsertedIDs))
+			for index, id := range result.UpsertedIDs {
+				upsertedIDs[strconv.Itoa(modelItems[index])] = id
+			}
+			summary["insertedCount"] = result.InsertedCount
+			summary["matchedCount"] = result.MatchedCount
+			summary["modifiedCount"] = result.ModifiedCount
+			summary["deletedCount"] = result.DeletedCount
+			summary["upsertedCount"] = result.UpsertedCount
+			summary["upsertedIds"] = upsertedIDs
+		}
+	}
+
+	summary["errors"] = itemErrors
+	summary["errorCount"] = len(itemErrors)
+
+	output := "default"
+	if len(itemErrors) > 0 {
+		output = "partial"
+	}
+
+	log.Printf("✅ Bulk write to %s.%s: %d items, %d errors", n.Database, n.Collection, len(items), len(itemErrors))
+
+	return workflow.NodeResult{
+		Output: output,
+		Data: map[string]interface{}{
+			n.OutputKey: summary,
+		},
+	}, nil
+}
+
+// itemModel resolves the operation for one item. Only the fields of the
+// resolved operation type are resolved, so a templated type can share one
+// operation between inserts and deletes.
+func (n *MongoDBBulkWriteNode) itemModel(itemCtx map[string]interface{}) (mongo.WriteModel, error) {
+	typeValue, err := n.Resolver.Resolve(n.Operation["type"], itemCtx)
+	if err != nil {
+		return nil, err
+	}
+	operationType, ok := typeValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("operation type must resolve to a string")
+	}
+	fields, err := bulkOperationFields(operationType)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]interface{}, len(fields))
+	for key := range fields {
+		value, exists := n.Operation[key]
+		if !exists {
+			continue
+		}
+		if resolved[key], err = n.Resolver.Resolve(value, itemCtx); err != nil {
+			return nil, err
+		}
+	}
+	return bulkWriteModel(operationType, resolved, n.AllowEmptyFilter, nil)
+}
+
+func bulkItemError(index int, code int, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"index": index,
+		"code":  code,
+		"error": message,
+	}
+}
+
+func (n *MongoDBBulkWriteNode) Outputs() []string {
+	return []string{"default", "partial"}
+}
@@ -0,0 +1,146 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+func TestValidateBulkOperation(t *testing.T) {
+	document := map[string]interface{}{"name": "{{item.name}}"}
+	filter := map[string]interface{}{"_id": "{{item.id}}"}
+	update := map[string]interface{}{"$set": map[string]interface{}{"name": "{{item.name}}"}}
+
+	tests := []struct {
+		name             string
+		operation        map[string]interface{}
+		allowEmptyFilter bool
+		// err is the expected error, empty when the operation is valid
+		err string
+	}{
+		{name: "insert", operation: map[string]interface{}{"type": "insert", "document": document}},
+		{name: "update", operation: map[string]interface{}{"type": "update", "filter": filter, "update": update}},
+		{name: "upsert", operation: map[string]interface{}{"type": "upsert", "filter": filter, "update": update}},
+		{name: "delete", operation: map[string]interface{}{"type": "delete", "filter": filter}},
+		{name: "templated document", operation: map[string]interface{}{"type": "insert", "document": "{{item}}"}},
+		{name: "type not a string", operation: map[string]interface{}{"type": 1.0}, err: "operation type must be a string"},
+		{name: "unknown type", operation: map[string]interface{}{"type": "replace"}, err: "unknown operation type: replace (use insert, update, upsert or delete)"},
+		{
+			name:      "unknown field",
+			operation: map[string]interface{}{"type": "insert", "document": document, "upsert": true},
+			err:       "operation: unknown field upsert",
+		},
+		{
+			name:      "field the type doesn't take",
+			operation: map[string]interface{}{"type": "insert", "document": document, "filter": filter},
+			err:       "operation insert does not take filter",
+		},
+		{name: "missing document", operation: map[string]interface{}{"type": "insert"}, err: "operation insert requires document"},
+		{name: "missing filter", operation: map[string]interface{}{"type": "delete"}, err: "operation delete requires filter"},
+		{
+			name:      "missing filter next to a templated update",
+			operation: map[string]interface{}{"type": "update", "update": "{{item.changes}}"},
+			err:       "operation update requires filter",
+		},
+		{name: "missing update", operation: map[string]interface{}{"type": "update", "filter": filter}, err: "operation update requires update"},
+		{name: "document not an object", operation: map[string]interface{}{"type": "insert", "document": []interface{}{document}}, err: "operation document must be an object"},
+		{
+			name:      "update without operators",
+			operation: map[string]interface{}{"type": "update", "filter": filter, "update": document},
+			err:       "operation update must only contain update operators such as $set, got field name",
+		},
+		{
+			name:      "empty delete filter",
+			operation: map[string]interface{}{"type": "delete", "filter": map[string]interface{}{}},
+			err:       "operation delete: filter must not be empty unless allowEmptyFilter is true",
+		},
+		{
+			name:             "empty delete filter allowed",
+			operation:        map[string]interface{}{"type": "delete", "filter": map[string]interface{}{}},
+			allowEmptyFilter: true,
+		},
+		{name: "templated delete filter", operation: map[string]interface{}{"type": "delete", "filter": "{{item.filter}}"}},
+		{
+			name:      "templated type skips the per-type checks",
+			operation: map[string]interface{}{"type": "{{item.kind}}", "document": document, "filter": map[string]interface{}{}},
+		},
+		{
+			name:      "templated type with an unknown field",
+			operation: map[string]interface{}{"type": "{{item.kind}}", "document": document, "replacement": document},
+			err:       "operation: unknown field replacement",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBulkOperation(tt.operation, tt.allowEmptyFilter)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("validateBulkOperation() error = %v", err)
+			case tt.err != "" && (err == nil || err.Error() != tt.err):
+				t.Errorf("validateBulkOperation() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestMongoDBBulkWriteBuildChecks(t *testing.T) {
+	insert := map[string]interface{}{"type": "insert", "document": "{{item}}"}
+
+	tests := []buildCheck{
+		{name: "missing items", config: map[string]interface{}{"operation": insert}, err: "items is required"},
+		{name: "items not a template or array", config: map[string]interface{}{"items": 3.0, "operation": insert}, err: "items must be a template"},
+		{name: "operation not an object", config: map[string]interface{}{"items": "{{users}}", "operation": "insert"}, err: "operation must be an object"},
+		{
+			name:   "empty delete filter",
+			config: map[string]interface{}{"items": "{{users}}", "operation": map[string]interface{}{"type": "delete", "filter": map[string]interface{}{}}},
+			err:    "filter must not be empty unless allowEmptyFilter is true",
+		},
+		{name: "ordered not a boolean", config: map[string]interface{}{"items": "{{users}}", "operation": insert, "ordered": "no"}, err: "ordered must be a boolean"},
+		{name: "empty itemKey", config: map[string]interface{}{"items": "{{users}}", "operation": insert, "itemKey": ""}, err: "itemKey must be a non-empty string"},
+	}
+
+	runBuildChecks(t, "mongodb_bulk_write", NewMongoDBBulkWriteNode, map[string]interface{}{"database": "db", "collection": "users"}, tests)
+}
+
+// TestMongoDBBulkWriteItemErrors checks that items whose templated type
+// doesn't resolve to an operation are reported without reaching the server.
+func TestMongoDBBulkWriteItemErrors(t *testing.T) {
+	tests := []struct {
+		ordered bool
+		indexes []interface{}
+	}{
+		{true, []interface{}{0}},
+		{false, []interface{}{0, 1}},
+	}
+
+	for _, tt := range tests {
+		node, err := NewMongoDBBulkWriteNode(workflow.NodeDefinition{ID: "bulk", Type: "mongodb_bulk_write", Config: map[string]interface{}{
+			"database":   "db",
+			"collection": "users",
+			"items":      "{{users}}",
+			"ordered":    tt.ordered,
+			"operation":  map[string]interface{}{"type": "{{item.kind}}", "document": "{{item}}"},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := node.Execute(map[string]interface{}{"users": []interface{}{
+			map[string]interface{}{"kind": "replace"},
+			map[string]interface{}{"kind": 1.0},
+		}})
+		if err != nil {
+			t.Fatalf("ordered %v: Execute() error = %v", tt.ordered, err)
+		}
+
+		summary := result.Data["bulkWrite"].(map[string]interface{})
+		var indexes []interface{}
+		for _, itemErr := range summary["errors"].([]interface{}) {
+			indexes = append(indexes, itemErr.(map[string]interface{})["index"])
+		}
+		if result.Output != "partial" || summary["itemCount"] != 2 || !reflect.DeepEqual(indexes, tt.indexes) {
+			t.Errorf("ordered %v: Execute() = %s, %v", tt.ordered, result.Output, summary)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBCountNode counts the documents matching a filter exactly, without
+// the limit that caps the count of a find node.
+type MongoDBCountNode struct {
+	ID         string
+	Database   string
+	Collection string
+	Resolver   Resolver
+	Filter     map[string]interface{}
+	Skip       int64
+	Limit      int64 // 0 means no limit
+	Hint       interface{}
+	Collation  *options.Collation
+	MaxTime    time.Duration // 0 means no limit
+	OutputKey  string
+}
+
+func NewMongoDBCountNode(def workflow.NodeDefinition) (*MongoDBCountNode, error) {
+	// Extract and validate database
+	database, ok := def.Config["database"].(string)
+	if !ok {
+		return nil, fmt.Errorf("database must be a string")
+	}
+
+	// Extract and validate collection
+	collection, ok := def.Config["collection"].(string)
+	if !ok {
+		return nil, fmt.Errorf("collection must be a string")
+	}
+
+	// An absent filter counts every document
+	filter := map[string]interface{}{}
+	if filterValue, exists := def.Config["filter"]; exists {
+		if filter, ok = filterValue.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("filter must be an object")
+		}
+	}
+
+	var skip int64
+	if skipValue, exists := def.Config["skip"]; exists {
+		skipFloat, ok := skipValue.(float64)
+		if !ok || skipFloat < 0 {
+			return nil, fmt.Errorf("skip must be a non-negative number")
+		}
+		skip = int64(skipFloat)
+	}
+
+	var limit int64
+	if limitValue, exists := def.Config["limit"]; exists {
+		limitFloat, ok := limitValue.(float64)
+		if !ok || limitFloat < 1 {
+			return nil, fmt.Errorf("limit must be a positive number")
+		}
+		limit = int64(limitFloat)
+	}
+
+	var hint interface{}
+	if hintValue, exists := def.Config["hint"]; exists {
+		parsed, err := parseHint(hintValue)
+		if err != nil {
+			return nil, err
+		}
+		hint = parsed
+	}
+
+	var collation *options.Collation
+	if collationValue, exists := def.Config["collation"]; exists {
+		parsed, err := parseCollation(collationValue)
+		if err != nil {
+			return nil, err
+		}
+		collation = parsed
+	}
+
+	maxTime, err := maxTimeFromConfig(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	outputKey := "count" // default
+	if keyValue, exists := def.Config["outputKey"]; exists {
+		if key, ok := keyValue.(string); ok {
+			outputKey = key
+		}
+	}
+
+	resolver, err := NewResolver(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoDBCountNode{
+		ID:         def.ID,
+		Database:   database,
+		Collection: collection,
+		Resolver:   resolver,
+		Filter:     filter,
+		Skip:       skip,
+		Limit:      limit,
+		Hint:       hint,
+		Collation:  collation,
+		MaxTime:    maxTime,
+		OutputKey:  outputKey,
+	}, nil
+}
+
+func (n *MongoDBCountNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	return n.ExecuteContext(context.Background(), ctx)
+}
+
+func (n *MongoDBCountNode) ExecuteContext(goCtx context.Context, ctx map[string]interface{}) (workflow.NodeResult, error) {
+	resolvedFilter, err := n.Resolver.ResolveMap(n.Filter, ctx)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to resolve filter: %w", err)
+	}
+
+	opts := options.Count()
+	if n.Skip > 0 {
+		opts.SetSkip(n.Skip)
+	}
+	if n.Limit > 0 {
+		opts.SetLimit(n.Limit)
+	}
+	if n.Hint != nil {
+		opts.SetHint(n.Hint)
+	}
+	if n.Collation != nil {
+		opts.SetCollation(n.Collation)
+	}
+	if n.MaxTime > 0 {
+		opts.SetMaxTime(n.MaxTime)
+	}
+
+	collection := MongoClient.Database(n.Database).Collection(n.Collection)
+	count, err := collection.CountDocuments(goCtx, resolvedFilter, opts)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	log.Printf("Counted %d documents in %s.%s", count, n.Database, n.Collection)
+
+	return workflow.NodeResult{
+		Output: "default",
+		Data: map[string]interface{}{
+			n.OutputKey: count,
+		},
+	}, nil
+}
+
+func (n *MongoDBCountNode) Outputs() []string {
+	return []string{"default"}
+}
@@ -0,0 +1,131 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMongoDBCountBuildChecks(t *testing.T) {
+	tests := []buildCheck{
+		{name: "no filter counts everything", config: map[string]interface{}{}},
+		{name: "every option", config: map[string]interface{}{
+			"filter":    map[string]interface{}{"status": "{{status}}"},
+			"skip":      10.0,
+			"limit":     100.0,
+			"hint":      map[string]interface{}{"status": 1.0},
+			"collation": map[string]interface{}{"locale": "en"},
+			"maxTimeMS": 1000.0,
+		}},
+		{name: "filter not an object", config: map[string]interface{}{"filter": []interface{}{}}, err: "filter must be an object"},
+		{name: "negative skip", config: map[string]interface{}{"skip": -5.0}, err: "skip must be a non-negative number"},
+		{name: "zero limit", config: map[string]interface{}{"limit": 0.0}, err: "limit must be a positive number"},
+		{name: "limit not a number", config: map[string]interface{}{"limit": "10"}, err: "limit must be a positive number"},
+		{name: "invalid hint", config: map[string]interface{}{"hint": 1.0}, err: "hint must be an object or an array of objects"},
+		{name: "collation without locale", config: map[string]interface{}{"collation": map[string]interface{}{}}, err: "collation: locale is required"},
+		{name: "negative maxTimeMS", config: map[string]interface{}{"maxTimeMS": -1.0}, err: "maxTimeMS must be a positive number"},
+	}
+
+	runBuildChecks(t, "mongodb_count", NewMongoDBCountNode, map[string]interface{}{"database": "db", "collection": "users"}, tests)
+}
+
+func TestMongoDBDistinctBuildChecks(t *testing.T) {
+	tests := []buildCheck{
+		{name: "field", config: map[string]interface{}{"field": "country"}},
+		{name: "nested field with filter", config: map[string]interface{}{"field": "address.city", "filter": map[string]interface{}{"active": true}}},
+		{name: "missing field", config: map[string]interface{}{}, err: "field must be a non-empty string"},
+		{name: "empty field", config: map[string]interface{}{"field": ""}, err: "field must be a non-empty string"},
+		{name: "field not a string", config: map[string]interface{}{"field": []interface{}{"country"}}, err: "field must be a non-empty string"},
+		{name: "filter not an object", config: map[string]interface{}{"field": "country", "filter": "{{filter}}"}, err: "filter must be an object"},
+		{name: "collation strength", config: map[string]interface{}{"field": "country", "collation": map[string]interface{}{"locale": "en", "strength": 0.0}}, err: "collation: invalid value for strength"},
+		{name: "zero maxTimeMS", config: map[string]interface{}{"field": "country", "maxTimeMS": 0.0}, err: "maxTimeMS must be a positive number"},
+	}
+
+	runBuildChecks(t, "mongodb_distinct", NewMongoDBDistinctNode, map[string]interface{}{"database": "db", "collection": "users"}, tests)
+}
+
+func TestMongoDBCountExecute(t *testing.T) {
+	tests := []struct {
+		name  string
+		batch []bson.D
+		want  int64
+	}{
+		{"matches", []bson.D{{{Key: "_id", Value: 1}, {Key: "n", Value: 3}}}, 3},
+		// The count pipeline returns no document when nothing matches
+		{"no matches", nil, 0},
+	}
+
+	mt := newMockMongo(t)
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			useMockClient(mt)
+			node, err := NewMongoDBCountNode(workflow.NodeDefinition{ID: "count", Type: "mongodb_count", Config: map[string]interface{}{
+				"database":   "db",
+				"collection": "users",
+				"filter":     map[string]interface{}{"status": "{{status}}"},
+				"outputKey":  "activeUsers",
+			}})
+			if err != nil {
+				mt.Fatal(err)
+			}
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, tt.batch...))
+
+			result, err := node.Execute(map[string]interface{}{"status": "active"})
+			if err != nil {
+				mt.Fatalf("Execute() error = %v", err)
+			}
+			if want := (map[string]interface{}{"activeUsers": tt.want}); !reflect.DeepEqual(result.Data, want) {
+				mt.Errorf("Execute() = %v, want %v", result.Data, want)
+			}
+
+			match := sentCommand(mt)["pipeline"].(bson.A)[0]
+			if want := (bson.M{"$match": bson.M{"status": "active"}}); !reflect.DeepEqual(match, want) {
+				mt.Errorf("$match stage = %v, want %v", match, want)
+			}
+		})
+	}
+}
+
+func TestMongoDBDistinctExecute(t *testing.T) {
+	tests := []struct {
+		name   string
+		values bson.A
+		want   map[string]interface{}
+	}{
+		{"values", bson.A{"FR", "US"}, map[string]interface{}{"values": []interface{}{"FR", "US"}, "valuesCount": 2}},
+		{"no values", bson.A{}, map[string]interface{}{"values": []interface{}{}, "valuesCount": 0}},
+	}
+
+	mt := newMockMongo(t)
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			useMockClient(mt)
+			node, err := NewMongoDBDistinctNode(workflow.NodeDefinition{ID: "distinct", Type: "mongodb_distinct", Config: map[string]interface{}{
+				"database":   "db",
+				"collection": "users",
+				"field":      "country",
+				"filter":     map[string]interface{}{"status": "{{status}}"},
+			}})
+			if err != nil {
+				mt.Fatal(err)
+			}
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "values", Value: tt.values}))
+
+			result, err := node.Execute(map[string]interface{}{"status": "active"})
+			if err != nil {
+				mt.Fatalf("Execute() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Data, tt.want) {
+				mt.Errorf("Execute() = %v, want %v", result.Data, tt.want)
+			}
+
+			command := sentCommand(mt)
+			if want := (bson.M{"status": "active"}); command["key"] != "country" || !reflect.DeepEqual(command["query"], want) {
+				mt.Errorf("distinct command = %v, want key country and query %v", command, want)
+			}
+		})
+	}
+}
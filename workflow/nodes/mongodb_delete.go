@@ -0,0 +1,134 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoDBDeleteNode struct {
+	ID               string
+	Database         string
+	Collection       string
+	Resolver         Resolver
+	Operation        string      // deleteOne or deleteMany
+	Filter           interface{} // an object, or a template resolving to one
+	AllowEmptyFilter bool
+}
+
+func NewMongoDBDeleteNode(def workflow.NodeDefinition) (*MongoDBDeleteNode, error) {
+	// Extract and validate database
+	database, ok := def.Config["database"].(string)
+	if !ok {
+		return nil, fmt.Errorf("database must be a string")
+	}
+
+	// Extract and validate collection
+	collection, ok := def.Config["collection"].(string)
+	if !ok {
+		return nil, fmt.Errorf("collection must be a string")
+	}
+
+	operation := "deleteOne" // default
+	if operationValue, exists := def.Config["operation"]; exists {
+		operationStr, ok := operationValue.(string)
+		if !ok || (operationStr != "deleteOne" && operationStr != "deleteMany") {
+			return nil, fmt.Errorf("operation must be deleteOne or deleteMany")
+		}
+		operation = operationStr
+	}
+
+	allowEmptyFilter := false
+	if allowValue, exists := def.Config["allowEmptyFilter"]; exists {
+		if allowEmptyFilter, ok = allowValue.(bool); !ok {
+			return nil, fmt.Errorf("allowEmptyFilter must be a boolean")
+		}
+	}
+
+	var filter interface{} = map[string]interface{}{}
+	if filterValue, exists := def.Config["filter"]; exists {
+		switch filterValue.(type) {
+		case map[string]interface{}, string:
+			filter = filterValue
+		default:
+			return nil, fmt.Errorf("filter must be an object or a template")
+		}
+	}
+
+	// A templated filter is only checked once resolved
+	if filterMap, ok := filter.(map[string]interface{}); ok {
+		if err := checkDeleteFilter(filterMap, allowEmptyFilter); err != nil {
+			return nil, err
+		}
+	}
+
+	resolver, err := NewResolver(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoDBDeleteNode{
+		ID:               def.ID,
+		Database:         database,
+		Collection:       collection,
+		Resolver:         resolver,
+		Operation:        operation,
+		Filter:           filter,
+		AllowEmptyFilter: allowEmptyFilter,
+	}, nil
+}
+
+// checkDeleteFilter rejects an empty filter, which matches every document in
+// the collection, unless allowEmptyFilter is set.
+func checkDeleteFilter(filter map[string]interface{}, allowEmptyFilter bool) error {
+	if len(filter) == 0 && !allowEmptyFilter {
+		return fmt.Errorf("filter must not be empty unless allowEmptyFilter is true")
+	}
+	return nil
+}
+
+func (n *MongoDBDeleteNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	return n.ExecuteContext(context.Background(), ctx)
+}
+
+func (n *MongoDBDeleteNode) ExecuteContext(goCtx context.Context, ctx map[string]interface{}) (workflow.NodeResult, error) {
+	resolved, err := n.Resolver.Resolve(n.Filter, ctx)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to resolve filter: %w", err)
+	}
+	resolvedFilter, ok := resolved.(map[string]interface{})
+	if !ok {
+		return workflow.NodeResult{}, fmt.Errorf("filter must resolve to an object, got %T", resolved)
+	}
+	if err := checkDeleteFilter(resolvedFilter, n.AllowEmptyFilter); err != nil {
+		return workflow.NodeResult{}, err
+	}
+
+	collection := MongoClient.Database(n.Database).Collection(n.Collection)
+
+	var result *mongo.DeleteResult
+	if n.Operation == "deleteMany" {
+		result, err = collection.DeleteMany(goCtx, resolvedFilter)
+	} else {
+		result, err = collection.DeleteOne(goCtx, resolvedFilter)
+	}
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to delete documents: %w", err)
+	}
+
+	log.Printf("🗑️ Deleted %d documents from %s.%s", result.DeletedCount, n.Database, n.Collection)
+
+	return workflow.NodeResult{
+		Output: "default",
+		Data: map[string]interface{}{
+			"deletedCount": result.DeletedCount,
+		},
+	}, nil
+}
+
+func (n *MongoDBDeleteNode) Outputs() []string {
+	return []string{"default"}
+}
@@ -0,0 +1,134 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMongoDBDeleteEmptyFilterGuard(t *testing.T) {
+	tests := []buildCheck{
+		{name: "filter", config: map[string]interface{}{"filter": map[string]interface{}{"_id": "{{userId}}"}}},
+		{name: "missing filter", config: map[string]interface{}{}, err: "filter must not be empty unless allowEmptyFilter is true"},
+		{name: "empty filter", config: map[string]interface{}{"filter": map[string]interface{}{}}, err: "filter must not be empty unless allowEmptyFilter is true"},
+		{
+			name:   "empty filter on deleteMany",
+			config: map[string]interface{}{"operation": "deleteMany", "filter": map[string]interface{}{}},
+			err:    "filter must not be empty unless allowEmptyFilter is true",
+		},
+		{
+			name:   "empty filter explicitly allowed",
+			config: map[string]interface{}{"operation": "deleteMany", "filter": map[string]interface{}{}, "allowEmptyFilter": true},
+		},
+		{name: "missing filter explicitly allowed", config: map[string]interface{}{"allowEmptyFilter": true}},
+		{
+			name:   "allowEmptyFilter false",
+			config: map[string]interface{}{"filter": map[string]interface{}{}, "allowEmptyFilter": false},
+			err:    "filter must not be empty unless allowEmptyFilter is true",
+		},
+		{name: "allowEmptyFilter not a boolean", config: map[string]interface{}{"allowEmptyFilter": "true"}, err: "allowEmptyFilter must be a boolean"},
+		{name: "templated filter", config: map[string]interface{}{"filter": "{{criteria}}"}},
+		{name: "filter not an object", config: map[string]interface{}{"filter": 3.0}, err: "filter must be an object or a template"},
+		{name: "unknown operation", config: map[string]interface{}{"operation": "drop", "filter": map[string]interface{}{"a": 1.0}}, err: "operation must be deleteOne or deleteMany"},
+	}
+
+	runBuildChecks(t, "mongodb_delete", NewMongoDBDeleteNode, map[string]interface{}{"database": "db", "collection": "users"}, tests)
+}
+
+func TestMongoDBDeleteExecute(t *testing.T) {
+	tests := []struct {
+		operation string
+		limit     int32
+	}{
+		{"deleteOne", 1},
+		{"deleteMany", 0},
+	}
+
+	mt := newMockMongo(t)
+	for _, tt := range tests {
+		mt.Run(tt.operation, func(mt *mtest.T) {
+			useMockClient(mt)
+			node, err := NewMongoDBDeleteNode(workflow.NodeDefinition{ID: "delete", Type: "mongodb_delete", Config: map[string]interface{}{
+				"database":   "db",
+				"collection": "users",
+				"operation":  tt.operation,
+				"filter":     map[string]interface{}{"status": "{{status}}"},
+			}})
+			if err != nil {
+				mt.Fatal(err)
+			}
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}))
+
+			result, err := node.Execute(map[string]interface{}{"status": "inactive"})
+			if err != nil {
+				mt.Fatalf("Execute() error = %v", err)
+			}
+			if want := (map[string]interface{}{"deletedCount": int64(2)}); !reflect.DeepEqual(result.Data, want) {
+				mt.Errorf("Execute() = %v, want %v", result.Data, want)
+			}
+
+			statement := sentCommand(mt)["deletes"].(bson.A)[0].(bson.M)
+			if want := (bson.M{"status": "inactive"}); !reflect.DeepEqual(statement["q"], want) {
+				mt.Errorf("filter = %v, want %v", statement["q"], want)
+			}
+			if statement["limit"] != tt.limit {
+				mt.Errorf("limit = %v, want %d", statement["limit"], tt.limit)
+			}
+		})
+	}
+}
+
+// TestMongoDBDeleteResolvedFilter checks that a templated filter is only sent
+// once it resolves to an object that is not empty, or empty and allowed.
+func TestMongoDBDeleteResolvedFilter(t *testing.T) {
+	tests := []struct {
+		name             string
+		criteria         interface{}
+		allowEmptyFilter bool
+		err              string
+	}{
+		{name: "filter", criteria: map[string]interface{}{"status": "inactive"}},
+		{name: "empty filter", criteria: map[string]interface{}{}, err: "filter must not be empty unless allowEmptyFilter is true"},
+		{name: "empty filter allowed", criteria: map[string]interface{}{}, allowEmptyFilter: true},
+		{name: "filter not an object", criteria: "inactive", err: "filter must resolve to an object, got string"},
+	}
+
+	mt := newMockMongo(t)
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			useMockClient(mt)
+			node, err := NewMongoDBDeleteNode(workflow.NodeDefinition{ID: "delete", Type: "mongodb_delete", Config: map[string]interface{}{
+				"database":         "db",
+				"collection":       "users",
+				"operation":        "deleteMany",
+				"filter":           "{{criteria}}",
+				"allowEmptyFilter": tt.allowEmptyFilter,
+			}})
+			if err != nil {
+				mt.Fatal(err)
+			}
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+
+			_, err = node.Execute(map[string]interface{}{"criteria": tt.criteria})
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					mt.Errorf("Execute() error = %v, want %q", err, tt.err)
+				}
+				if started := mt.GetStartedEvent(); started != nil {
+					mt.Errorf("%s was sent", started.CommandName)
+				}
+				return
+			}
+			if err != nil {
+				mt.Fatalf("Execute() error = %v", err)
+			}
+			statement := sentCommand(mt)["deletes"].(bson.A)[0].(bson.M)
+			if want := bson.M(tt.criteria.(map[string]interface{})); !reflect.DeepEqual(statement["q"], want) {
+				mt.Errorf("filter = %v, want %v", statement["q"], want)
+			}
+		})
+	}
+}
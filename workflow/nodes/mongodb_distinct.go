@@ -0,0 +1,133 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBDistinctNode returns every distinct value of a field across the
+// documents matching a filter.
+type MongoDBDistinctNode struct {
+	ID         string
+	Database   string
+	Collection string
+	Resolver   Resolver
+	Field      string
+	Filter     map[string]interface{}
+	Collation  *options.Collation
+	MaxTime    time.Duration // 0 means no limit
+	OutputKey  string
+}
+
+func NewMongoDBDistinctNode(def workflow.NodeDefinition) (*MongoDBDistinctNode, error) {
+	// Extract and validate database
+	database, ok := def.Config["database"].(string)
+	if !ok {
+		return nil, fmt.Errorf("database must be a string")
+	}
+
+	// Extract and validate collection
+	collection, ok := def.Config["collection"].(string)
+	if !ok {
+		return nil, fmt.Errorf("collection must be a string")
+	}
+
+	// Extract and validate field
+	field, ok := def.Config["field"].(string)
+	if !ok || field == "" {
+		return nil, fmt.Errorf("field must be a non-empty string")
+	}
+
+	// An absent filter looks at every document
+	filter := map[string]interface{}{}
+	if filterValue, exists := def.Config["filter"]; exists {
+		if filter, ok = filterValue.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("filter must be an object")
+		}
+	}
+
+	var collation *options.Collation
+	if collationValue, exists := def.Config["collation"]; exists {
+		parsed, err := parseCollation(collationValue)
+		if err != nil {
+			return nil, err
+		}
+		collation = parsed
+	}
+
+	maxTime, err := maxTimeFromConfig(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	outputKey := "values" // default
+	if keyValue, exists := def.Config["outputKey"]; exists {
+		if key, ok := keyValue.(string); ok {
+			outputKey = key
+		}
+	}
+
+	resolver, err := NewResolver(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoDBDistinctNode{
+		ID:         def.ID,
+		Database:   database,
+		Collection: collection,
+		Resolver:   resolver,
+		Field:      field,
+		Filter:     filter,
+		Collation:  collation,
+		MaxTime:    maxTime,
+		OutputKey:  outputKey,
+	}, nil
+}
+
+func (n *MongoDBDistinctNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	return n.ExecuteContext(context.Background(), ctx)
+}
+
+func (n *MongoDBDistinctNode) ExecuteContext(goCtx context.Context, ctx map[string]interface{}) (workflow.NodeResult, error) {
+	resolvedFilter, err := n.Resolver.ResolveMap(n.Filter, ctx)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to resolve filter: %w", err)
+	}
+
+	opts := options.Distinct()
+	if n.Collation != nil {
+		opts.SetCollation(n.Collation)
+	}
+	if n.MaxTime > 0 {
+		opts.SetMaxTime(n.MaxTime)
+	}
+
+	collection := MongoClient.Database(n.Database).Collection(n.Collection)
+	values, err := collection.Distinct(goCtx, n.Field, resolvedFilter, opts)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to find distinct values: %w", err)
+	}
+	if values == nil {
+		values = []interface{}{}
+	}
+
+	log.Printf("Found %d distinct values of %s in %s.%s", len(values), n.Field, n.Database, n.Collection)
+
+	return workflow.NodeResult{
+		Output: "default",
+		Data: map[string]interface{}{
+			n.OutputKey:           values,
+			n.OutputKey + "Count": len(values),
+		},
+	}, nil
+}
+
+func (n *MongoDBDistinctNode) Outputs() []string {
+	return []string{"default"}
+}
@@ -2,10 +2,14 @@ package nodes
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -13,9 +17,30 @@ type MongoDBFindNode struct {
 	ID         string
 	Database   string
 	Collection string
+	Resolver   Resolver
 	Query      map[string]interface{}
 	Limit      int64
 	OutputKey  string
+	Sort       bson.D
+	Projection map[string]interface{}
+	Skip       int64
+	Hint       interface{}
+	Collation  *options.Collation
+	MaxTime    time.Duration // 0 means no limit
+	FindOne    bool
+
+	// Pagination, enabled by cursorField or cursor. Cursor is the template
+	// for the last value of CursorField seen on the previous page.
+	Paginate    bool
+	CursorField string
+	Cursor      interface{}
+
+	// Streaming, enabled by stream: true. See Stream.
+	Streaming   bool
+	BatchSize   int
+	Concurrency int
+	ItemKey     string
+	OnItemError string // stop or continue
 }
 
 func NewMongoDBFindNode(def workflow.NodeDefinition) (*MongoDBFindNode, error) {
@@ -53,35 +78,172 @@ func NewMongoDBFindNode(def workflow.NodeDefinition) (*MongoDBFindNode, error) {
 		}
 	}
 
-	return &MongoDBFindNode{
+	var sort bson.D
+	if sortValue, exists := def.Config["sort"]; exists {
+		spec, err := parseSortSpec(sortValue)
+		if err != nil {
+			return nil, fmt.Errorf("sort %w", err)
+		}
+		sort = spec
+	}
+
+	var projection map[string]interface{}
+	if projectionValue, exists := def.Config["projection"]; exists {
+		if projection, ok = projectionValue.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("projection must be an object")
+		}
+	}
+
+	var skip int64
+	if skipValue, exists := def.Config["skip"]; exists {
+		skipFloat, ok := skipValue.(float64)
+		if !ok || skipFloat < 0 {
+			return nil, fmt.Errorf("skip must be a non-negative number")
+		}
+		skip = int64(skipFloat)
+	}
+
+	var hint interface{}
+	if hintValue, exists := def.Config["hint"]; exists {
+		parsed, err := parseHint(hintValue)
+		if err != nil {
+			return nil, err
+		}
+		hint = parsed
+	}
+
+	var collation *options.Collation
+	if collationValue, exists := def.Config["collation"]; exists {
+		parsed, err := parseCollation(collationValue)
+		if err != nil {
+			return nil, err
+		}
+		collation = parsed
+	}
+
+	maxTime, err := maxTimeFromConfig(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	findOne := false
+	if findOneValue, exists := def.Config["findOne"]; exists {
+		if findOne, ok = findOneValue.(bool); !ok {
+			return nil, fmt.Errorf("findOne must be a boolean")
+		}
+	}
+
+	node := &MongoDBFindNode{
 		ID:         def.ID,
 		Database:   database,
 		Collection: collection,
 		Query:      filter,
 		Limit:      limit,
 		OutputKey:  outputKey,
-	}, nil
+		Sort:       sort,
+		Projection: projection,
+		Skip:       skip,
+		Hint:       hint,
+		Collation:  collation,
+		MaxTime:    maxTime,
+		FindOne:    findOne,
+	}
+	if err := node.configurePagination(def.Config); err != nil {
+		return nil, err
+	}
+	if err := node.configureStreaming(def.Config); err != nil {
+		return nil, err
+	}
+
+	resolver, err := NewResolver(def.Config)
+	if err != nil {
+		return nil, err
+	}
+	node.Resolver = resolver
+
+	return node, nil
+}
+
+// configurePagination sets up cursor-based paging. Pages are ordered by the
+// cursor field alone, so it should be unique (the default, _id, always is):
+// each page continues after the last value of the previous one, which stays
+// correct when documents are inserted or deleted between pages.
+func (n *MongoDBFindNode) configurePagination(config map[string]interface{}) error {
+	cursorFieldValue, hasField := config["cursorField"]
+	cursor, hasCursor := config["cursor"]
+	if !hasField && !hasCursor {
+		return nil
+	}
+
+	if n.FindOne {
+		return fmt.Errorf("findOne cannot be combined with cursor pagination")
+	}
+	if n.Limit <= 0 {
+		return fmt.Errorf("limit must be positive when paginating")
+	}
+	// Every page already starts after the cursor, skipping would drop documents
+	if n.Skip > 0 {
+		return fmt.Errorf("skip cannot be combined with cursor pagination")
+	}
+
+	cursorField := "_id" // default
+	if hasField {
+		field, ok := cursorFieldValue.(string)
+		if !ok || field == "" {
+			return fmt.Errorf("cursorField must be a non-empty string")
+		}
+		cursorField = field
+	}
+
+	if n.Sort == nil {
+		n.Sort = bson.D{{Key: cursorField, Value: int32(1)}}
+	} else if len(n.Sort) != 1 || n.Sort[0].Key != cursorField {
+		return fmt.Errorf("sort must only use the cursor field %s when paginating", cursorField)
+	}
+
+	n.Paginate = true
+	n.CursorField = cursorField
+	n.Cursor = cursor
+	return nil
 }
 
 func (n *MongoDBFindNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
-	resolvedQuery, err := ResolveMapValues(n.Query, ctx)
+	return n.ExecuteContext(context.Background(), ctx)
+}
+
+func (n *MongoDBFindNode) ExecuteContext(goCtx context.Context, ctx map[string]interface{}) (workflow.NodeResult, error) {
+	if n.Streaming {
+		// Outside the engine there is no item sub-graph to feed
+		return n.Stream(goCtx, ctx, func(map[string]interface{}) error { return nil })
+	}
+
+	resolvedQuery, err := n.Resolver.ResolveMap(n.Query, ctx)
 	if err != nil {
 		return workflow.NodeResult{}, fmt.Errorf("failed to resolve query: %w", err)
 	}
 
+	if n.FindOne {
+		return n.executeFindOne(goCtx, resolvedQuery)
+	}
+
+	if n.Paginate {
+		if resolvedQuery, err = n.applyCursor(resolvedQuery, ctx); err != nil {
+			return workflow.NodeResult{}, err
+		}
+	}
+
 	log.Printf("Finding documents in %s.%s with query: %v", n.Database, n.Collection, resolvedQuery)
 
-	
 	collection := MongoClient.Database(n.Database).Collection(n.Collection)
-	cursor, err := collection.Find(context.Background(), resolvedQuery, options.Find().SetLimit(n.Limit))
+	cursor, err := collection.Find(goCtx, resolvedQuery, n.findOptions())
 	if err != nil {
 		return workflow.NodeResult{}, fmt.Errorf("failed to find documents: %w", err)
 	}
 
-	defer cursor.Close(context.Background())
+	defer cursor.Close(goCtx)
 
 	results := make([]map[string]interface{}, 0)
-	for cursor.Next(context.Background()) {
+	for cursor.Next(goCtx) {
 		var result map[string]interface{}
 		if err := cursor.Decode(&result); err != nil {
 			return workflow.NodeResult{}, fmt.Errorf("failed to decode document: %w", err)
@@ -89,13 +251,158 @@ func (n *MongoDBFindNode) Execute(ctx map[string]interface{}) (workflow.NodeResu
 		results = append(results, result)
 	}
 
-	ctx[n.OutputKey] = results
-	ctx[n.OutputKey+"Count"] = len(results)
+	if err := cursor.Err(); err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to find documents: %w", err)
+	}
 
 	log.Printf("Found %d documents in %s.%s", len(results), n.Database, n.Collection)
 
+	data := map[string]interface{}{
+		n.OutputKey:           results,
+		n.OutputKey + "Count": len(results),
+	}
+	if n.Paginate {
+		nextCursor, err := n.nextCursor(results)
+		if err != nil {
+			return workflow.NodeResult{}, err
+		}
+		data[n.OutputKey+"NextCursor"] = nextCursor
+	}
+
 	return workflow.NodeResult{
 		Output: "default",
-		Data:   ctx,
+		Data:   data,
 	}, nil
 }
+
+func (n *MongoDBFindNode) findOptions() *options.FindOptions {
+	opts := options.Find().SetLimit(n.Limit)
+	if n.Sort != nil {
+		opts.SetSort(n.Sort)
+	}
+	if n.Projection != nil {
+		opts.SetProjection(n.Projection)
+	}
+	if n.Skip > 0 {
+		opts.SetSkip(n.Skip)
+	}
+	if n.Hint != nil {
+		opts.SetHint(n.Hint)
+	}
+	if n.Collation != nil {
+		opts.SetCollation(n.Collation)
+	}
+	if n.MaxTime > 0 {
+		opts.SetMaxTime(n.MaxTime)
+	}
+	return opts
+}
+
+func (n *MongoDBFindNode) executeFindOne(goCtx context.Context, query map[string]interface{}) (workflow.NodeResult, error) {
+	log.Printf("Finding one document in %s.%s with query: %v", n.Database, n.Collection, query)
+
+	opts := options.FindOne()
+	if n.Sort != nil {
+		opts.SetSort(n.Sort)
+	}
+	if n.Projection != nil {
+		opts.SetProjection(n.Projection)
+	}
+	if n.Skip > 0 {
+		opts.SetSkip(n.Skip)
+	}
+	if n.Hint != nil {
+		opts.SetHint(n.Hint)
+	}
+	if n.Collation != nil {
+		opts.SetCollation(n.Collation)
+	}
+	if n.MaxTime > 0 {
+		opts.SetMaxTime(n.MaxTime)
+	}
+
+	collection := MongoClient.Database(n.Database).Collection(n.Collection)
+	var result map[string]interface{}
+	err := collection.FindOne(goCtx, query, opts).Decode(&result)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return workflow.NodeResult{}, fmt.Errorf("failed to find document: %w", err)
+	}
+
+	count := 0
+	if result != nil {
+		count = 1
+	}
+	log.Printf("Found %d documents in %s.%s", count, n.Database, n.Collection)
+
+	return workflow.NodeResult{
+		Output: "default",
+		Data: map[string]interface{}{
+			n.OutputKey:           result,
+			n.OutputKey + "Count": count,
+		},
+	}, nil
+}
+
+// applyCursor restricts the query to documents after the cursor. A cursor
+// that is missing, null or empty starts at the first page.
+func (n *MongoDBFindNode) applyCursor(query map[string]interface{}, ctx map[string]interface{}) (map[string]interface{}, error) {
+	if n.Cursor == nil {
+		return query, nil
+	}
+
+	cursor, err := n.Resolver.Resolve(n.Cursor, ctx)
+	if err != nil {
+		var missing *MissingVariableError
+		if errors.As(err, &missing) {
+			return query, nil
+		}
+		return nil, fmt.Errorf("failed to resolve cursor: %w", err)
+	}
+	if cursor == nil || cursor == "" {
+		return query, nil
+	}
+
+	operator := "$gt"
+	if n.Sort[0].Value == int32(-1) {
+		operator = "$lt"
+	}
+	after := map[string]interface{}{
+		n.CursorField: map[string]interface{}{operator: cursorValue(n.CursorField, cursor)},
+	}
+	if len(query) == 0 {
+		return after, nil
+	}
+	return map[string]interface{}{"$and": []interface{}{query, after}}, nil
+}
+
+// nextCursor returns the cursor for the next page, or nil when this page was
+// not full and there is nothing left to read.
+func (n *MongoDBFindNode) nextCursor(results []map[string]interface{}) (interface{}, error) {
+	if int64(len(results)) < n.Limit {
+		return nil, nil
+	}
+
+	segments, err := splitPath(n.CursorField)
+	if err != nil {
+		return nil, err
+	}
+	value, exists := walkPath(results[len(results)-1], segments)
+	if !exists {
+		return nil, fmt.Errorf("cursor field %s is missing from the results, include it in the projection", n.CursorField)
+	}
+	return value, nil
+}
+
+func (n *MongoDBFindNode) Outputs() []string {
+	if n.Streaming {
+		return []string{"default", workflow.StreamItemOutput}
+	}
+	return []string{"default"}
+}
+
+func (n *MongoDBFindNode) MaxConcurrency() int {
+	if n.Streaming {
+		return n.Concurrency
+	}
+	return 1
+}
@@ -0,0 +1,248 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+// maxStreamErrors caps how many item errors the stream stats keep.
+const maxStreamErrors = 100
+
+// configureStreaming sets up streaming mode, where the documents are fed
+// through the sub-graph on the "item" output as they are read instead of
+// being collected into the context.
+func (n *MongoDBFindNode) configureStreaming(config map[string]interface{}) error {
+	streamValue, exists := config["stream"]
+	if !exists {
+		return nil
+	}
+	stream, ok := streamValue.(bool)
+	if !ok {
+		return fmt.Errorf("stream must be a boolean")
+	}
+	if !stream {
+		return nil
+	}
+
+	if n.FindOne {
+		return fmt.Errorf("findOne cannot be combined with stream")
+	}
+	if n.Paginate {
+		return fmt.Errorf("cursor pagination cannot be combined with stream")
+	}
+
+	// Streams read every matching document unless a limit is set explicitly
+	if _, exists := config["limit"]; !exists {
+		n.Limit = 0
+	}
+
+	batchSize := 1 // default
+	if batchValue, exists := config["batchSize"]; exists {
+		batchFloat, ok := batchValue.(float64)
+		if !ok || batchFloat < 1 {
+			return fmt.Errorf("batchSize must be a positive number")
+		}
+		batchSize = int(batchFloat)
+	}
+
+	concurrency := 1 // default
+	if concurrencyValue, exists := config["concurrency"]; exists {
+		concurrencyFloat, ok := concurrencyValue.(float64)
+		if !ok || concurrencyFloat < 1 {
+			return fmt.Errorf("concurrency must be a positive number")
+		}
+		concurrency = int(concurrencyFloat)
+	}
+
+	itemKey := "item" // default
+	if keyValue, exists := config["itemKey"]; exists {
+		key, ok := keyValue.(string)
+		if !ok || key == "" {
+			return fmt.Errorf("itemKey must be a non-empty string")
+		}
+		itemKey = key
+	}
+
+	onItemError := "stop" // default
+	if onErrorValue, exists := config["onItemError"]; exists {
+		onErrorStr, ok := onErrorValue.(string)
+		if !ok || (onErrorStr != "stop" && onErrorStr != "continue") {
+			return fmt.Errorf("onItemError must be stop or continue")
+		}
+		onItemError = onErrorStr
+	}
+
+	n.Streaming = true
+	n.BatchSize = batchSize
+	n.Concurrency = concurrency
+	n.ItemKey = itemKey
+	n.OnItemError = onItemError
+	return nil
+}
+
+type streamJob struct {
+	index int
+	size  int
+	item  map[string]interface{}
+}
+
+// streamStats aggregates the outcome of every streamed item. Workers report
+// to it concurrently.
+type streamStats struct {
+	mutex     sync.Mutex
+	items     int
+	succeeded int
+	failed    int
+	errors    []interface{}
+	stopErr   error
+}
+
+// record stores the result of one item and reports whether the stream
+// should stop reading.
+func (s *streamStats) record(job streamJob, err error, onItemError string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.items++
+	if err == nil {
+		s.succeeded += job.size
+		return false
+	}
+
+	s.failed += job.size
+	if len(s.errors) < maxStreamErrors {
+		s.errors = append(s.errors, map[string]interface{}{
+			"index": job.index,
+			"error": err.Error(),
+		})
+	}
+	if onItemError == "stop" && s.stopErr == nil {
+		s.stopErr = fmt.Errorf("item %d: %w", job.index, err)
+	}
+	return onItemError == "stop"
+}
+
+// Stream reads the cursor and emits each document, or each batch of
+// BatchSize documents, to the "item" sub-graph on up to Concurrency workers.
+// The cursor is only advanced when a worker is free to take the next item,
+// so at most Concurrency items are in flight and one more is being read.
+func (n *MongoDBFindNode) Stream(goCtx context.Context, ctx map[string]interface{}, emit func(item map[string]interface{}) error) (workflow.NodeResult, error) {
+	if !n.Streaming {
+		return n.ExecuteContext(goCtx, ctx)
+	}
+
+	started := time.Now()
+	resolvedQuery, err := n.Resolver.ResolveMap(n.Query, ctx)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to resolve query: %w", err)
+	}
+
+	log.Printf("Streaming documents from %s.%s with query: %v", n.Database, n.Collection, resolvedQuery)
+
+	readCtx, cancel := context.WithCancel(goCtx)
+	defer cancel()
+
+	collection := MongoClient.Database(n.Database).Collection(n.Collection)
+	cursor, err := collection.Find(readCtx, resolvedQuery, n.findOptions())
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to find documents: %w", err)
+	}
+	defer cursor.Close(goCtx)
+
+	stats := &streamStats{}
+	jobs := make(chan streamJob)
+	var wg sync.WaitGroup
+	for i := 0; i < n.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if stats.record(job, emit(job.item), n.OnItemError) {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	documents := 0
+	index := 0
+	var batch []interface{}
+	send := func() bool {
+		if readCtx.Err() != nil {
+			return false
+		}
+		job := streamJob{index: index, size: len(batch), item: n.streamItem(index, batch)}
+		batch = nil
+		index++
+		select {
+		case jobs <- job:
+			return true
+		case <-readCtx.Done():
+			return false
+		}
+	}
+
+	var readErr error
+	for cursor.Next(readCtx) {
+		var document map[string]interface{}
+		if err := cursor.Decode(&document); err != nil {
+			readErr = fmt.Errorf("failed to decode document: %w", err)
+			break
+		}
+		documents++
+		batch = append(batch, document)
+		if len(batch) == n.BatchSize && !send() {
+			break
+		}
+	}
+	if readErr == nil && len(batch) > 0 {
+		send()
+	}
+	close(jobs)
+	wg.Wait()
+
+	if stats.stopErr != nil {
+		return workflow.NodeResult{}, stats.stopErr
+	}
+	if readErr != nil {
+		return workflow.NodeResult{}, readErr
+	}
+	if err := cursor.Err(); err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to find documents: %w", err)
+	}
+
+	log.Printf("Streamed %d documents from %s.%s in %d items (%d failed)", documents, n.Database, n.Collection, stats.items, stats.failed)
+
+	return workflow.NodeResult{
+		Output: "default",
+		Data: map[string]interface{}{
+			n.OutputKey + "Count": documents,
+			n.OutputKey + "Stats": map[string]interface{}{
+				"documents":  documents,
+				"items":      stats.items,
+				"succeeded":  stats.succeeded,
+				"failed":     stats.failed,
+				"errors":     append([]interface{}{}, stats.errors...),
+				"durationMs": time.Since(started).Milliseconds(),
+			},
+		},
+	}, nil
+}
+
+// streamItem builds the data an item's sub-graph sees: the document, or the
+// array of documents when batching, and its position in the stream.
+func (n *MongoDBFindNode) streamItem(index int, batch []interface{}) map[string]interface{} {
+	var value interface{} = batch
+	if n.BatchSize == 1 {
+		value = batch[0]
+	}
+	return map[string]interface{}{
+		n.ItemKey:           value,
+		n.ItemKey + "Index": index,
+	}
+}
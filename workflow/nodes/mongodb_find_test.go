@@ -0,0 +1,224 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+var findBase = map[string]interface{}{"database": "db", "collection": "users", "filter": map[string]interface{}{}}
+
+func newMongoDBFind(config map[string]interface{}) (*MongoDBFindNode, error) {
+	full := make(map[string]interface{})
+	for key, value := range findBase {
+		full[key] = value
+	}
+	for key, value := range config {
+		full[key] = value
+	}
+	return NewMongoDBFindNode(workflow.NodeDefinition{ID: "find", Type: "mongodb_find", Config: full})
+}
+
+func TestMongoDBFindBuildChecks(t *testing.T) {
+	tests := []buildCheck{
+		{name: "sort, projection, skip and hint", config: map[string]interface{}{
+			"sort":       []interface{}{map[string]interface{}{"age": -1.0}, map[string]interface{}{"name": "asc"}},
+			"projection": map[string]interface{}{"name": 1.0},
+			"skip":       20.0,
+			"hint":       "age_1",
+		}},
+		{name: "sort object with several keys", config: map[string]interface{}{"sort": map[string]interface{}{"age": -1.0, "name": 1.0}}, err: "sort object must have a single key"},
+		{name: "sort direction", config: map[string]interface{}{"sort": map[string]interface{}{"age": 2.0}}, err: "sort age: direction must be 1, -1"},
+		{name: "duplicate sort field", config: map[string]interface{}{"sort": []interface{}{map[string]interface{}{"age": 1.0}, map[string]interface{}{"age": -1.0}}}, err: "sort has duplicate field age"},
+		{name: "negative skip", config: map[string]interface{}{"skip": -1.0}, err: "skip must be a non-negative number"},
+		{name: "empty hint", config: map[string]interface{}{"hint": ""}, err: "hint must not be empty"},
+		{name: "collation without locale", config: map[string]interface{}{"collation": map[string]interface{}{"strength": 2.0}}, err: "collation: locale is required"},
+		{name: "collation strength", config: map[string]interface{}{"collation": map[string]interface{}{"locale": "en", "strength": 6.0}}, err: "collation: invalid value for strength"},
+		{name: "unknown collation field", config: map[string]interface{}{"collation": map[string]interface{}{"locale": "en", "case": "upper"}}, err: "collation: unknown field case"},
+		{name: "pagination", config: map[string]interface{}{"cursor": "{{after}}", "limit": 50.0}},
+		{name: "pagination with skip", config: map[string]interface{}{"cursor": "{{after}}", "skip": 10.0}, err: "skip cannot be combined with cursor pagination"},
+		{name: "pagination with findOne", config: map[string]interface{}{"cursorField": "_id", "findOne": true}, err: "findOne cannot be combined with cursor pagination"},
+		{name: "pagination without limit", config: map[string]interface{}{"cursor": "{{after}}", "limit": 0.0}, err: "limit must be positive when paginating"},
+		{name: "pagination sorted on another field", config: map[string]interface{}{"cursorField": "email", "sort": map[string]interface{}{"age": 1.0}}, err: "sort must only use the cursor field email when paginating"},
+		{name: "empty cursorField", config: map[string]interface{}{"cursorField": ""}, err: "cursorField must be a non-empty string"},
+	}
+
+	runBuildChecks(t, "mongodb_find", NewMongoDBFindNode, findBase, tests)
+}
+
+func TestMongoDBFindPaginationSort(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		field  string
+		sort   bson.D
+	}{
+		{"default cursor field", map[string]interface{}{"cursor": "{{after}}"}, "_id", bson.D{{Key: "_id", Value: int32(1)}}},
+		{"custom cursor field", map[string]interface{}{"cursorField": "email"}, "email", bson.D{{Key: "email", Value: int32(1)}}},
+		{"descending", map[string]interface{}{"cursorField": "_id", "sort": map[string]interface{}{"_id": "desc"}}, "_id", bson.D{{Key: "_id", Value: int32(-1)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := newMongoDBFind(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !node.Paginate || node.CursorField != tt.field || !reflect.DeepEqual(node.Sort, tt.sort) {
+				t.Errorf("paginate %v on %q sorted %v, want %q sorted %v", node.Paginate, node.CursorField, node.Sort, tt.field, tt.sort)
+			}
+		})
+	}
+}
+
+func TestMongoDBFindStreamBuildChecks(t *testing.T) {
+	tests := []buildCheck{
+		{name: "stream", config: map[string]interface{}{"stream": true, "batchSize": 10.0, "concurrency": 4.0, "itemKey": "user", "onItemError": "continue"}},
+		{name: "stream false", config: map[string]interface{}{"stream": false, "batchSize": 0.0}},
+		{name: "stream not a boolean", config: map[string]interface{}{"stream": "true"}, err: "stream must be a boolean"},
+		{name: "stream with findOne", config: map[string]interface{}{"stream": true, "findOne": true}, err: "findOne cannot be combined with stream"},
+		{name: "stream with pagination", config: map[string]interface{}{"stream": true, "cursor": "{{after}}"}, err: "cursor pagination cannot be combined with stream"},
+		{name: "zero batchSize", config: map[string]interface{}{"stream": true, "batchSize": 0.0}, err: "batchSize must be a positive number"},
+		{name: "zero concurrency", config: map[string]interface{}{"stream": true, "concurrency": 0.0}, err: "concurrency must be a positive number"},
+		{name: "empty itemKey", config: map[string]interface{}{"stream": true, "itemKey": ""}, err: "itemKey must be a non-empty string"},
+		{name: "unknown onItemError", config: map[string]interface{}{"stream": true, "onItemError": "retry"}, err: "onItemError must be stop or continue"},
+	}
+
+	runBuildChecks(t, "mongodb_find", NewMongoDBFindNode, findBase, tests)
+}
+
+func TestMongoDBFindStreamDefaults(t *testing.T) {
+	node, err := newMongoDBFind(map[string]interface{}{"stream": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Streams read every document unless limit is set
+	if !node.Streaming || node.Limit != 0 || node.BatchSize != 1 || node.Concurrency != 1 || node.ItemKey != "item" || node.OnItemError != "stop" {
+		t.Errorf("stream defaults = %+v", node)
+	}
+	if node.MaxConcurrency() != 1 {
+		t.Errorf("MaxConcurrency() = %d, want 1", node.MaxConcurrency())
+	}
+
+	node, err = newMongoDBFind(map[string]interface{}{"stream": true, "limit": 5.0, "concurrency": 3.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Limit != 5 || node.MaxConcurrency() != 3 {
+		t.Errorf("limit %d, MaxConcurrency() %d, want 5 and 3", node.Limit, node.MaxConcurrency())
+	}
+}
+
+// TestMongoDBFindPages reads two pages, passing the first page's
+// resultsNextCursor back in as the cursor of the second.
+func TestMongoDBFindPages(t *testing.T) {
+	mt := newMockMongo(t)
+	mt.Run("pages", func(mt *mtest.T) {
+		useMockClient(mt)
+		node, err := newMongoDBFind(map[string]interface{}{"cursor": "{{after}}", "limit": 2.0})
+		if err != nil {
+			mt.Fatal(err)
+		}
+		user := func(id int32) bson.D { return bson.D{{Key: "_id", Value: id}} }
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, user(1), user(2)),
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, user(3)),
+		)
+
+		first, err := node.Execute(map[string]interface{}{})
+		if err != nil {
+			mt.Fatalf("first page: Execute() error = %v", err)
+		}
+		if first.Data["resultsCount"] != 2 || first.Data["resultsNextCursor"] != int32(2) {
+			mt.Fatalf("first page = %v, want 2 results and next cursor 2", first.Data)
+		}
+		if filter := sentCommand(mt)["filter"]; !reflect.DeepEqual(filter, bson.M{}) {
+			mt.Errorf("first page filter = %v, want {}", filter)
+		}
+
+		second, err := node.Execute(map[string]interface{}{"after": first.Data["resultsNextCursor"]})
+		if err != nil {
+			mt.Fatalf("second page: Execute() error = %v", err)
+		}
+		// A page shorter than the limit is the last one
+		if second.Data["resultsCount"] != 1 || second.Data["resultsNextCursor"] != nil {
+			mt.Errorf("second page = %v, want 1 result and no next cursor", second.Data)
+		}
+		want := bson.M{"_id": bson.M{"$gt": int32(2)}}
+		if filter := sentCommand(mt)["filter"]; !reflect.DeepEqual(filter, want) {
+			mt.Errorf("second page filter = %v, want %v", filter, want)
+		}
+	})
+}
+
+func TestMongoDBFindStreamItemErrors(t *testing.T) {
+	tests := []struct {
+		onItemError string
+		err         string
+		stats       map[string]interface{}
+	}{
+		{
+			onItemError: "stop",
+			err:         "item 1: no email",
+		},
+		{
+			onItemError: "continue",
+			stats: map[string]interface{}{
+				"documents": 3,
+				"items":     3,
+				"succeeded": 2,
+				"failed":    1,
+				"errors":    []interface{}{map[string]interface{}{"index": 1, "error": "no email"}},
+			},
+		},
+	}
+
+	mt := newMockMongo(t)
+	for _, tt := range tests {
+		mt.Run(tt.onItemError, func(mt *mtest.T) {
+			useMockClient(mt)
+			node, err := newMongoDBFind(map[string]interface{}{"stream": true, "itemKey": "user", "onItemError": tt.onItemError})
+			if err != nil {
+				mt.Fatal(err)
+			}
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch,
+				bson.D{{Key: "email", Value: "a@example.com"}},
+				bson.D{},
+				bson.D{{Key: "email", Value: "c@example.com"}},
+			))
+
+			var emitted []interface{}
+			result, err := node.Stream(context.Background(), map[string]interface{}{}, func(item map[string]interface{}) error {
+				emitted = append(emitted, item["userIndex"])
+				if _, ok := item["user"].(map[string]interface{})["email"]; !ok {
+					return errors.New("no email")
+				}
+				return nil
+			})
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					mt.Fatalf("Stream() error = %v, want %q", err, tt.err)
+				}
+				// The stream stops reading after the failed item
+				if !reflect.DeepEqual(emitted, []interface{}{0, 1}) {
+					mt.Errorf("emitted items %v, want [0 1]", emitted)
+				}
+				return
+			}
+			if err != nil {
+				mt.Fatalf("Stream() error = %v", err)
+			}
+
+			stats := result.Data["resultsStats"].(map[string]interface{})
+			delete(stats, "durationMs")
+			if result.Data["resultsCount"] != 3 || !reflect.DeepEqual(stats, tt.stats) {
+				mt.Errorf("Stream() = %v, want stats %v", result.Data, tt.stats)
+			}
+		})
+	}
+}
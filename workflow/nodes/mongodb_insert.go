@@ -12,6 +12,7 @@ type MongoDBInsertNode struct {
 	ID         string
 	Database   string
 	Collection string
+	Resolver   Resolver
 	Document   map[string]interface{}
 }
 
@@ -34,32 +35,46 @@ func NewMongoDBInsertNode(def workflow.NodeDefinition) (*MongoDBInsertNode, erro
 		return nil, fmt.Errorf("document must be an object")
 	}
 
+	resolver, err := NewResolver(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &MongoDBInsertNode{
 		ID:         def.ID,
 		Database:   database,
 		Collection: collection,
+		Resolver:   resolver,
 		Document:   document,
 	}, nil
 }
 
 func (n *MongoDBInsertNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
-	resolvedDoc, err := ResolveMapValues(n.Document, ctx)
+	return n.ExecuteContext(context.Background(), ctx)
+}
+
+func (n *MongoDBInsertNode) ExecuteContext(goCtx context.Context, ctx map[string]interface{}) (workflow.NodeResult, error) {
+	resolvedDoc, err := n.Resolver.ResolveMap(n.Document, ctx)
 	if err != nil {
 		return workflow.NodeResult{}, fmt.Errorf("failed to resolve document values: %w", err)
 	}
 
 	collection := MongoClient.Database(n.Database).Collection(n.Collection)
-	result, err := collection.InsertOne(context.Background(), resolvedDoc)
+	result, err := collection.InsertOne(goCtx, resolvedDoc)
 	if err != nil {
 		return workflow.NodeResult{}, fmt.Errorf("failed to insert document: %w", err)
 	}
 
 	log.Printf("✅ Inserted document with ID: %v", result.InsertedID)
 
-	ctx["insertedID"] = result.InsertedID
-
 	return workflow.NodeResult{
 		Output: "default",
-		Data:   ctx,
+		Data: map[string]interface{}{
+			"insertedID": result.InsertedID,
+		},
 	}, nil
 }
+
+func (n *MongoDBInsertNode) Outputs() []string {
+	return []string{"default"}
+}
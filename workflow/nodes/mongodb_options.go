@@ -0,0 +1,153 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// parseSortSpec reads a sort or index key specification. JSON objects don't
+// keep their key order, so a spec with several keys must be an array of
+// single-key objects: [{"age": -1}, {"name": 1}]. A single-key object such as
+// {"age": -1} is accepted as a shorthand. Directions are 1, -1, "asc" or "desc".
+func parseSortSpec(value interface{}) (bson.D, error) {
+	var entries []interface{}
+	switch spec := value.(type) {
+	case map[string]interface{}:
+		if len(spec) != 1 {
+			return nil, fmt.Errorf("object must have a single key, use an array such as [{\"a\": 1}, {\"b\": -1}] to sort on several fields")
+		}
+		entries = []interface{}{spec}
+	case []interface{}:
+		if len(spec) == 0 {
+			return nil, fmt.Errorf("must not be empty")
+		}
+		entries = spec
+	default:
+		return nil, fmt.Errorf("must be an object or an array of objects")
+	}
+
+	sort := make(bson.D, 0, len(entries))
+	for _, entry := range entries {
+		entryMap, ok := entry.(map[string]interface{})
+		if !ok || len(entryMap) != 1 {
+			return nil, fmt.Errorf("entries must be objects with a single field")
+		}
+		for field, directionValue := range entryMap {
+			if field == "" {
+				return nil, fmt.Errorf("has an empty field name")
+			}
+			for _, existing := range sort {
+				if existing.Key == field {
+					return nil, fmt.Errorf("has duplicate field %s", field)
+				}
+			}
+			direction, err := parseSortDirection(directionValue)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field, err)
+			}
+			sort = append(sort, bson.E{Key: field, Value: direction})
+		}
+	}
+	return sort, nil
+}
+
+func parseSortDirection(value interface{}) (int32, error) {
+	switch direction := value.(type) {
+	case float64:
+		if direction == 1 || direction == -1 {
+			return int32(direction), nil
+		}
+	case string:
+		switch strings.ToLower(direction) {
+		case "asc", "ascending":
+			return 1, nil
+		case "desc", "descending":
+			return -1, nil
+		}
+	}
+	return 0, fmt.Errorf("direction must be 1, -1, \"asc\" or \"desc\"")
+}
+
+// parseHint reads an index hint: the index name, or its key specification
+// in the same form as sort.
+func parseHint(value interface{}) (interface{}, error) {
+	if name, ok := value.(string); ok {
+		if name == "" {
+			return nil, fmt.Errorf("hint must not be empty")
+		}
+		return name, nil
+	}
+	keys, err := parseSortSpec(value)
+	if err != nil {
+		return nil, fmt.Errorf("hint %w", err)
+	}
+	return keys, nil
+}
+
+// parseCollation reads a collation document such as
+// {"locale": "en", "strength": 2}.
+func parseCollation(value interface{}) (*options.Collation, error) {
+	collationMap, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("collation must be an object")
+	}
+
+	collation := &options.Collation{}
+	for key, fieldValue := range collationMap {
+		var valid bool
+		switch key {
+		case "locale":
+			collation.Locale, valid = fieldValue.(string)
+		case "caseFirst":
+			collation.CaseFirst, valid = fieldValue.(string)
+		case "alternate":
+			collation.Alternate, valid = fieldValue.(string)
+		case "maxVariable":
+			collation.MaxVariable, valid = fieldValue.(string)
+		case "caseLevel":
+			collation.CaseLevel, valid = fieldValue.(bool)
+		case "numericOrdering":
+			collation.NumericOrdering, valid = fieldValue.(bool)
+		case "normalization":
+			collation.Normalization, valid = fieldValue.(bool)
+		case "backwards":
+			collation.Backwards, valid = fieldValue.(bool)
+		case "strength":
+			var strength float64
+			strength, valid = fieldValue.(float64)
+			valid = valid && strength >= 1 && strength <= 5 && strength == float64(int(strength))
+			collation.Strength = int(strength)
+		default:
+			return nil, fmt.Errorf("collation: unknown field %s", key)
+		}
+		if !valid {
+			return nil, fmt.Errorf("collation: invalid value for %s", key)
+		}
+	}
+
+	if collation.Locale == "" {
+		return nil, fmt.Errorf("collation: locale is required")
+	}
+	return collation, nil
+}
+
+// cursorValue prepares a pagination cursor for use in a filter. An _id cursor
+// that went through JSON, such as an ObjectID returned by an earlier page,
+// arrives as its hex string and is turned back into an ObjectID. Cursors on
+// other fields are used as they are, so string values that happen to look
+// like hex are still compared as strings.
+func cursorValue(field string, value interface{}) interface{} {
+	if field != "_id" {
+		return value
+	}
+	if str, ok := value.(string); ok && len(str) == 24 {
+		if id, err := primitive.ObjectIDFromHex(str); err == nil {
+			return id
+		}
+	}
+	return value
+}
@@ -0,0 +1,33 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorValue(t *testing.T) {
+	hex := "652f1c2e9b1d8a3f4c5e6d7a"
+	id, _ := primitive.ObjectIDFromHex(hex)
+
+	tests := []struct {
+		name  string
+		field string
+		value interface{}
+		want  interface{}
+	}{
+		{"_id hex string", "_id", hex, id},
+		{"_id short string", "_id", "652f", "652f"},
+		{"_id number", "_id", 42.0, 42.0},
+		{"hex string on another field", "sku", hex, hex},
+		{"other field", "createdAt", "2024-01-01", "2024-01-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cursorValue(tt.field, tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cursorValue(%q, %v) = %#v, want %#v", tt.field, tt.value, got, tt.want)
+			}
+		})
+	}
+}
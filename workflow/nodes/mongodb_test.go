@@ -0,0 +1,71 @@
+package nodes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// buildCheck is a node config to build, merged over the test's base config.
+type buildCheck struct {
+	name   string
+	config map[string]interface{}
+	// err is a substring of the expected build error, empty when the node is valid
+	err string
+}
+
+// runBuildChecks builds a node of nodeType with build for every check and
+// compares the build error.
+func runBuildChecks[N any](t *testing.T, nodeType string, build func(workflow.NodeDefinition) (N, error), base map[string]interface{}, tests []buildCheck) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := make(map[string]interface{}, len(base)+len(tt.config))
+			for key, value := range base {
+				config[key] = value
+			}
+			for key, value := range tt.config {
+				config[key] = value
+			}
+			_, err := build(workflow.NodeDefinition{ID: "node", Type: nodeType, Config: config})
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("build %s error = %v", nodeType, err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("build %s error = %v, want %q", nodeType, err, tt.err)
+			}
+		})
+	}
+}
+
+// newMockMongo returns a test whose sub-tests run against a mock deployment
+// that answers each command with the next response added to it.
+func newMockMongo(t *testing.T) *mtest.T {
+	return mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+}
+
+// useMockClient points MongoClient at the sub-test's mock deployment until
+// the sub-test ends.
+func useMockClient(mt *mtest.T) {
+	previous := MongoClient
+	MongoClient = mt.Client
+	mt.Cleanup(func() { MongoClient = previous })
+}
+
+// sentCommand returns the oldest command sent to the mock deployment that
+// has not been looked at yet.
+func sentCommand(mt *mtest.T) bson.M {
+	mt.Helper()
+	started := mt.GetStartedEvent()
+	if started == nil {
+		mt.Fatal("no command was sent")
+	}
+	var command bson.M
+	if err := bson.Unmarshal(started.Command, &command); err != nil {
+		mt.Fatal(err)
+	}
+	return command
+}
@@ -0,0 +1,105 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBTransactionNode runs the sub-graph on its "body" output inside a
+// MongoDB transaction. MongoDB nodes in the body share the transaction's
+// session, so their writes are committed together or not at all.
+type MongoDBTransactionNode struct {
+	ID            string
+	MaxCommitTime time.Duration // 0 means the server default
+	OutputKey     string
+}
+
+func NewMongoDBTransactionNode(def workflow.NodeDefinition) (*MongoDBTransactionNode, error) {
+	var maxCommitTime time.Duration
+	if maxCommitValue, exists := def.Config["maxCommitTimeMS"]; exists {
+		maxCommitMS, ok := maxCommitValue.(float64)
+		if !ok || maxCommitMS <= 0 {
+			return nil, fmt.Errorf("maxCommitTimeMS must be a positive number")
+		}
+		maxCommitTime = time.Duration(maxCommitMS * float64(time.Millisecond))
+	}
+
+	outputKey := "transaction" // default
+	if keyValue, exists := def.Config["outputKey"]; exists {
+		if key, ok := keyValue.(string); ok {
+			outputKey = key
+		}
+	}
+
+	return &MongoDBTransactionNode{
+		ID:            def.ID,
+		MaxCommitTime: maxCommitTime,
+		OutputKey:     outputKey,
+	}, nil
+}
+
+// RunScope starts a session and runs body in a transaction on it. The
+// driver retries the whole body on transient errors and the commit on
+// unknown commit results. Any other error, from the body or the commit,
+// aborts the transaction and is reported on the "aborted" output instead of
+// failing the workflow.
+func (n *MongoDBTransactionNode) RunScope(goCtx context.Context, ctx map[string]interface{}, body func(goCtx context.Context) error) (workflow.NodeResult, bool, error) {
+	session, err := MongoClient.StartSession()
+	if err != nil {
+		return workflow.NodeResult{}, false, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(goCtx)
+
+	opts := options.Transaction()
+	if n.MaxCommitTime > 0 {
+		opts.SetMaxCommitTime(&n.MaxCommitTime)
+	}
+
+	started := time.Now()
+	_, err = session.WithTransaction(goCtx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, body(sessionCtx)
+	}, opts)
+	duration := time.Since(started).Milliseconds()
+
+	if err != nil {
+		log.Printf("❌ Transaction %s aborted: %v", n.ID, err)
+		return workflow.NodeResult{
+			Output: "aborted",
+			Data: map[string]interface{}{
+				n.OutputKey: map[string]interface{}{
+					"committed":  false,
+					"error":      err.Error(),
+					"durationMs": duration,
+				},
+			},
+		}, false, nil
+	}
+
+	log.Printf("✅ Transaction %s committed", n.ID)
+	return workflow.NodeResult{
+		Output: "committed",
+		Data: map[string]interface{}{
+			n.OutputKey: map[string]interface{}{
+				"committed":  true,
+				"error":      nil,
+				"durationMs": duration,
+			},
+		},
+	}, true, nil
+}
+
+// Execute fails: the body is a sub-graph of the workflow, so a transaction
+// only runs inside the engine, which calls RunScope with it.
+func (n *MongoDBTransactionNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	return workflow.NodeResult{}, fmt.Errorf("transaction %s only runs inside the workflow engine", n.ID)
+}
+
+func (n *MongoDBTransactionNode) Outputs() []string {
+	return []string{workflow.ScopeBodyOutput, "committed", "aborted"}
+}
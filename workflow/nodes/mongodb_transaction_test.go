@@ -0,0 +1,87 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+// transactionWorkflow returns an engine for start -> tx, with tx wired to
+// the given outputs: a single update on "body" and a condition on any other.
+func transactionWorkflow(t *testing.T, outputs ...string) *workflow.Engine {
+	t.Helper()
+	engine := workflow.NewEngine()
+	engine.Workflow.Nodes = []workflow.NodeDefinition{
+		{ID: "start", Type: "start"},
+		{ID: "tx", Type: "mongodb_transaction", Config: map[string]interface{}{"maxCommitTimeMS": 500.0}},
+	}
+	engine.Workflow.Edges = []workflow.Edge{{From: "start", To: "tx", Output: "default"}}
+	for _, output := range outputs {
+		def := workflow.NodeDefinition{ID: "after-" + output, Type: "condition", Config: map[string]interface{}{"expression": "true"}}
+		if output == workflow.ScopeBodyOutput {
+			def = workflow.NodeDefinition{ID: "debit", Type: "mongodb_update", Config: map[string]interface{}{
+				"database":   "bank",
+				"collection": "accounts",
+				"filter":     map[string]interface{}{"_id": "{{from}}"},
+				"update":     map[string]interface{}{"$inc": map[string]interface{}{"balance": -10.0}},
+			}}
+		}
+		engine.Workflow.Nodes = append(engine.Workflow.Nodes, def)
+		engine.Workflow.Edges = append(engine.Workflow.Edges, workflow.Edge{From: "tx", To: def.ID, Output: output})
+	}
+	for _, def := range engine.Workflow.Nodes {
+		node, err := CreateNode(def)
+		if err != nil {
+			t.Fatalf("CreateNode(%s) error = %v", def.ID, err)
+		}
+		engine.Nodes[def.ID] = node
+	}
+	return engine
+}
+
+func TestMongoDBTransactionOutputs(t *testing.T) {
+	node, err := NewMongoDBTransactionNode(workflow.NodeDefinition{ID: "tx", Type: "mongodb_transaction"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ workflow.Scope = node
+	if got, want := node.Outputs(), []string{"body", "committed", "aborted"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Outputs() = %v, want %v", got, want)
+	}
+	if node.OutputKey != "transaction" || node.MaxCommitTime != 0 {
+		t.Errorf("defaults = %+v", node)
+	}
+	if _, err := node.Execute(nil); err == nil {
+		t.Error("Execute() outside the engine should fail")
+	}
+
+	if _, err := NewMongoDBTransactionNode(workflow.NodeDefinition{ID: "tx", Type: "mongodb_transaction", Config: map[string]interface{}{"maxCommitTimeMS": 0.0}}); err == nil {
+		t.Error("NewMongoDBTransactionNode() with maxCommitTimeMS 0 should fail")
+	}
+}
+
+func TestMongoDBTransactionWiring(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs []string
+		codes   []string
+	}{
+		{"body, committed and aborted", []string{"body", "committed", "aborted"}, nil},
+		{"body only", []string{"body"}, nil},
+		{"default output", []string{"body", "default"}, []string{"unknown_output"}},
+		{"success output", []string{"body", "success"}, []string{"unknown_output"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var codes []string
+			for _, problem := range transactionWorkflow(t, tt.outputs...).Validate() {
+				codes = append(codes, problem.Code)
+			}
+			if !reflect.DeepEqual(codes, tt.codes) {
+				t.Errorf("Validate() codes = %v, want %v", codes, tt.codes)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoDBUpdateNode struct {
+	ID         string
+	Database   string
+	Collection string
+	Resolver   Resolver
+	Operation  string // updateOne or updateMany
+	Filter     map[string]interface{}
+	Update     interface{} // update operators document or aggregation pipeline
+	Upsert     bool
+}
+
+func NewMongoDBUpdateNode(def workflow.NodeDefinition) (*MongoDBUpdateNode, error) {
+	// Extract and validate database
+	database, ok := def.Config["database"].(string)
+	if !ok {
+		return nil, fmt.Errorf("database must be a string")
+	}
+
+	// Extract and validate collection
+	collection, ok := def.Config["collection"].(string)
+	if !ok {
+		return nil, fmt.Errorf("collection must be a string")
+	}
+
+	operation := "updateOne" // default
+	if operationValue, exists := def.Config["operation"]; exists {
+		operationStr, ok := operationValue.(string)
+		if !ok || (operationStr != "updateOne" && operationStr != "updateMany") {
+			return nil, fmt.Errorf("operation must be updateOne or updateMany")
+		}
+		operation = operationStr
+	}
+
+	filter, ok := def.Config["filter"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("filter must be an object")
+	}
+
+	update, err := validateUpdate(def.Config["update"])
+	if err != nil {
+		return nil, err
+	}
+
+	upsert := false
+	if upsertValue, exists := def.Config["upsert"]; exists {
+		if upsert, ok = upsertValue.(bool); !ok {
+			return nil, fmt.Errorf("upsert must be a boolean")
+		}
+	}
+
+	resolver, err := NewResolver(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoDBUpdateNode{
+		ID:         def.ID,
+		Database:   database,
+		Collection: collection,
+		Resolver:   resolver,
+		Operation:  operation,
+		Filter:     filter,
+		Update:     update,
+		Upsert:     upsert,
+	}, nil
+}
+
+// validateUpdate accepts a document of update operators ({"$set": {...}})
+// or an aggregation pipeline (an array of stages). Plain documents would
+// replace the matched documents, which is not what an update node is for.
+func validateUpdate(value interface{}) (interface{}, error) {
+	switch update := value.(type) {
+	case map[string]interface{}:
+		if len(update) == 0 {
+			return nil, fmt.Errorf("update must not be empty")
+		}
+		for key := range update {
+			if !strings.HasPrefix(key, "$") {
+				return nil, fmt.Errorf("update must only contain update operators such as $set, got field %s", key)
+			}
+		}
+		return update, nil
+	case []interface{}:
+		if len(update) == 0 {
+			return nil, fmt.Errorf("update pipeline must not be empty")
+		}
+		for i, stage := range update {
+			if _, ok := stage.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("update pipeline stage %d must be an object", i)
+			}
+		}
+		return update, nil
+	default:
+		return nil, fmt.Errorf("update must be an object of update operators or an array of pipeline stages")
+	}
+}
+
+func (n *MongoDBUpdateNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	return n.ExecuteContext(context.Background(), ctx)
+}
+
+func (n *MongoDBUpdateNode) ExecuteContext(goCtx context.Context, ctx map[string]interface{}) (workflow.NodeResult, error) {
+	resolvedFilter, err := n.Resolver.ResolveMap(n.Filter, ctx)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to resolve filter: %w", err)
+	}
+
+	resolvedUpdate, err := n.Resolver.Resolve(n.Update, ctx)
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to resolve update: %w", err)
+	}
+
+	collection := MongoClient.Database(n.Database).Collection(n.Collection)
+	opts := options.Update().SetUpsert(n.Upsert)
+
+	var result *mongo.UpdateResult
+	if n.Operation == "updateMany" {
+		result, err = collection.UpdateMany(goCtx, resolvedFilter, resolvedUpdate, opts)
+	} else {
+		result, err = collection.UpdateOne(goCtx, resolvedFilter, resolvedUpdate, opts)
+	}
+	if err != nil {
+		return workflow.NodeResult{}, fmt.Errorf("failed to update documents: %w", err)
+	}
+
+	log.Printf("✅ Updated documents in %s.%s: matched %d, modified %d", n.Database, n.Collection, result.MatchedCount, result.ModifiedCount)
+
+	return workflow.NodeResult{
+		Output: "default",
+		Data: map[string]interface{}{
+			"matchedCount":  result.MatchedCount,
+			"modifiedCount": result.ModifiedCount,
+			"upsertedId":    result.UpsertedID,
+		},
+	}, nil
+}
+
+func (n *MongoDBUpdateNode) Outputs() []string {
+	return []string{"default"}
+}
@@ -0,0 +1,107 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMongoDBUpdateBuildChecks(t *testing.T) {
+	tests := []buildCheck{
+		{name: "update operators", config: map[string]interface{}{"update": map[string]interface{}{"$set": map[string]interface{}{"status": "active"}, "$inc": map[string]interface{}{"logins": 1.0}}}},
+		{name: "pipeline", config: map[string]interface{}{"update": []interface{}{map[string]interface{}{"$set": map[string]interface{}{"total": "$price"}}}}},
+		{
+			name:   "plain document",
+			config: map[string]interface{}{"update": map[string]interface{}{"status": "active"}},
+			err:    "update must only contain update operators such as $set, got field status",
+		},
+		{
+			name:   "operators mixed with a field",
+			config: map[string]interface{}{"update": map[string]interface{}{"$set": map[string]interface{}{"a": 1.0}, "b": 2.0}},
+			err:    "got field b",
+		},
+		{name: "empty update", config: map[string]interface{}{"update": map[string]interface{}{}}, err: "update must not be empty"},
+		{name: "missing update", config: map[string]interface{}{}, err: "update must be an object of update operators or an array of pipeline stages"},
+		{name: "empty pipeline", config: map[string]interface{}{"update": []interface{}{}}, err: "update pipeline must not be empty"},
+		{name: "pipeline stage not an object", config: map[string]interface{}{"update": []interface{}{"$set"}}, err: "update pipeline stage 0 must be an object"},
+		{name: "unknown operation", config: map[string]interface{}{"operation": "replaceOne", "update": map[string]interface{}{"$set": map[string]interface{}{}}}, err: "operation must be updateOne or updateMany"},
+		{name: "upsert not a boolean", config: map[string]interface{}{"upsert": "yes", "update": map[string]interface{}{"$set": map[string]interface{}{}}}, err: "upsert must be a boolean"},
+	}
+
+	runBuildChecks(t, "mongodb_update", NewMongoDBUpdateNode, map[string]interface{}{"database": "db", "collection": "users", "filter": map[string]interface{}{"_id": "{{userId}}"}}, tests)
+}
+
+func TestMongoDBUpdateExecute(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		response bson.D
+		want     map[string]interface{}
+	}{
+		{
+			name:     "updateOne",
+			config:   map[string]interface{}{},
+			response: mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			want:     map[string]interface{}{"matchedCount": int64(1), "modifiedCount": int64(1), "upsertedId": nil},
+		},
+		{
+			name:     "updateMany",
+			config:   map[string]interface{}{"operation": "updateMany"},
+			response: mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 3}, bson.E{Key: "nModified", Value: 2}),
+			want:     map[string]interface{}{"matchedCount": int64(3), "modifiedCount": int64(2), "upsertedId": nil},
+		},
+		{
+			name:   "upsert",
+			config: map[string]interface{}{"upsert": true},
+			response: mtest.CreateSuccessResponse(
+				bson.E{Key: "n", Value: 1},
+				bson.E{Key: "nModified", Value: 0},
+				bson.E{Key: "upserted", Value: bson.A{bson.D{{Key: "index", Value: 0}, {Key: "_id", Value: "u1"}}}},
+			),
+			want: map[string]interface{}{"matchedCount": int64(0), "modifiedCount": int64(0), "upsertedId": "u1"},
+		},
+	}
+
+	mt := newMockMongo(t)
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			useMockClient(mt)
+			config := map[string]interface{}{
+				"database":   "db",
+				"collection": "users",
+				"filter":     map[string]interface{}{"_id": "{{userId}}"},
+				"update":     map[string]interface{}{"$set": map[string]interface{}{"status": "{{status}}"}},
+			}
+			for key, value := range tt.config {
+				config[key] = value
+			}
+			node, err := NewMongoDBUpdateNode(workflow.NodeDefinition{ID: "update", Type: "mongodb_update", Config: config})
+			if err != nil {
+				mt.Fatal(err)
+			}
+			mt.AddMockResponses(tt.response)
+
+			result, err := node.Execute(map[string]interface{}{"userId": "u1", "status": "active"})
+			if err != nil {
+				mt.Fatalf("Execute() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Data, tt.want) {
+				mt.Errorf("Execute() = %v, want %v", result.Data, tt.want)
+			}
+
+			statement := sentCommand(mt)["updates"].(bson.A)[0].(bson.M)
+			if want := (bson.M{"_id": "u1"}); !reflect.DeepEqual(statement["q"], want) {
+				mt.Errorf("filter = %v, want %v", statement["q"], want)
+			}
+			if want := (bson.M{"$set": bson.M{"status": "active"}}); !reflect.DeepEqual(statement["u"], want) {
+				mt.Errorf("update = %v, want %v", statement["u"], want)
+			}
+			if multi := statement["multi"] == true; multi != (config["operation"] == "updateMany") {
+				mt.Errorf("multi = %v for %v", statement["multi"], config["operation"])
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// unaryOperators only look at the left-hand side; rhs is ignored.
+var unaryOperators = map[string]bool{
+	"exists":     true,
+	"notExists":  true,
+	"isEmpty":    true,
+	"isNotEmpty": true,
+}
+
+// isKnownOperator reports whether compareValues understands the operator.
+func isKnownOperator(operator string) bool {
+	switch operator {
+	case "==", "!=", ">", "<", ">=", "<=",
+		"contains", "startsWith", "endsWith", "matches", "in", "notIn", "between",
+		"lengthEq", "lengthNe", "lengthGt", "lengthGte", "lengthLt", "lengthLte",
+		"before", "after", "olderThan", "newerThan":
+		return true
+	}
+	return unaryOperators[operator]
+}
+
+// nullFalseOperators are false, rather than an error, when either operand is null.
+var nullFalseOperators = map[string]bool{
+	">": true, "<": true, ">=": true, "<=": true,
+	"startsWith": true, "endsWith": true, "matches": true, "between": true,
+	"lengthEq": true, "lengthNe": true, "lengthGt": true, "lengthGte": true, "lengthLt": true, "lengthLte": true,
+	"before": true, "after": true, "olderThan": true, "newerThan": true,
+}
+
+// compareValues applies an operator, converting operand types according to the coercion policy.
+// Operators are null-safe: comparing null never fails, see nullFalseOperators.
+func compareValues(lhs interface{}, rhs interface{}, operator string, coercion CoercionPolicy) (bool, error) {
+	lhsNull, rhsNull := normalizeValue(lhs) == nil, normalizeValue(rhs) == nil
+	switch {
+	case nullFalseOperators[operator] && (lhsNull || rhsNull):
+		return false, nil
+	case operator == "contains" && lhsNull:
+		return false, nil
+	case (operator == "in" || operator == "notIn") && rhsNull:
+		// A null list has no elements
+		return operator == "notIn", nil
+	}
+
+	switch operator {
+	case "==":
+		return valuesEqual(lhs, rhs, coercion), nil
+	case "!=":
+		return !valuesEqual(lhs, rhs, coercion), nil
+	case ">", "<", ">=", "<=":
+		order, err := orderValues(lhs, rhs, operator, coercion)
+		if err != nil {
+			return false, err
+		}
+		return checkOrder(order, operator), nil
+
+	case "contains":
+		if lhsStr, ok := lhs.(string); ok {
+			rhsStr, ok := rhs.(string)
+			if !ok {
+				return false, fmt.Errorf("operator contains on a string requires a string rhs, got %s", describeType(rhs))
+			}
+			return strings.Contains(lhsStr, rhsStr), nil
+		}
+		items, ok := toSlice(lhs)
+		if !ok {
+			return false, fmt.Errorf("operator contains requires a string or array lhs, got %s", describeType(lhs))
+		}
+		return sliceContains(items, rhs, coercion), nil
+	case "startsWith", "endsWith":
+		lhsStr, lhsOk := lhs.(string)
+		rhsStr, rhsOk := rhs.(string)
+		if !lhsOk || !rhsOk {
+			return false, fmt.Errorf("operator %s requires string operands, got %s and %s", operator, describeType(lhs), describeType(rhs))
+		}
+		if operator == "startsWith" {
+			return strings.HasPrefix(lhsStr, rhsStr), nil
+		}
+		return strings.HasSuffix(lhsStr, rhsStr), nil
+	case "matches":
+		lhsStr, lhsOk := lhs.(string)
+		if !lhsOk {
+			return false, fmt.Errorf("operator matches requires a string lhs and a pattern rhs, got %s and %s", describeType(lhs), describeType(rhs))
+		}
+		// Patterns known at build time arrive compiled, see ConditionNode.Pattern
+		if re, ok := rhs.(*regexp.Regexp); ok {
+			return re.MatchString(lhsStr), nil
+		}
+		pattern, ok := rhs.(string)
+		if !ok {
+			return false, fmt.Errorf("operator matches requires a string lhs and a pattern rhs, got %s and %s", describeType(lhs), describeType(rhs))
+		}
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(lhsStr), nil
+	case "in", "notIn":
+		items, ok := toSlice(rhs)
+		if !ok {
+			return false, fmt.Errorf("operator %s requires an array rhs, got %s", operator, describeType(rhs))
+		}
+		found := sliceContains(items, lhs, coercion)
+		if operator == "in" {
+			return found, nil
+		}
+		return !found, nil
+	case "between":
+		bounds, ok := toSlice(rhs)
+		if !ok || len(bounds) != 2 {
+			return false, fmt.Errorf("operator between requires an array rhs of [min, max], got %s", describeType(rhs))
+		}
+		lower, err := orderValues(lhs, bounds[0], operator, coercion)
+		if err != nil {
+			return false, err
+		}
+		upper, err := orderValues(lhs, bounds[1], operator, coercion)
+		if err != nil {
+			return false, err
+		}
+		return lower >= 0 && upper <= 0, nil
+
+	case "lengthEq", "lengthNe", "lengthGt", "lengthGte", "lengthLt", "lengthLte":
+		length, ok := valueLength(lhs)
+		if !ok {
+			return false, fmt.Errorf("operator %s requires a string, array or object lhs, got %s", operator, describeType(lhs))
+		}
+		expected, ok := toFloat64(rhs)
+		if !ok {
+			return false, fmt.Errorf("operator %s requires a number rhs, got %s", operator, describeType(rhs))
+		}
+		order, _ := orderValues(float64(length), expected, operator, coercion)
+		return checkOrder(order, lengthOperators[operator]), nil
+
+	case "before", "after", "olderThan", "newerThan":
+		return compareTimes(lhs, rhs, operator)
+
+	case "exists":
+		return !lhsNull, nil
+	case "notExists":
+		return lhsNull, nil
+	case "isEmpty", "isNotEmpty":
+		empty := isEmptyValue(lhs)
+		if operator == "isEmpty" {
+			return empty, nil
+		}
+		return !empty, nil
+
+	default:
+		return false, fmt.Errorf("unknown operator: %s", operator)
+	}
+}
+
+var lengthOperators = map[string]string{
+	"lengthEq":  "==",
+	"lengthNe":  "!=",
+	"lengthGt":  ">",
+	"lengthGte": ">=",
+	"lengthLt":  "<",
+	"lengthLte": "<=",
+}
+
+// valuesEqual compares numbers numerically, dates as instants, arrays and
+// documents element by element and everything else structurally.
+func valuesEqual(lhs interface{}, rhs interface{}, coercion CoercionPolicy) bool {
+	lhs, rhs = coerceOperands(lhs, rhs, coercion)
+
+	lhsFloat, lhsIsNum := lhs.(float64)
+	rhsFloat, rhsIsNum := rhs.(float64)
+	if lhsIsNum && rhsIsNum {
+		return lhsFloat == rhsFloat
+	}
+	if order, ok := orderTimes(lhs, rhs, coercion); ok {
+		return order == 0
+	}
+
+	if lhsItems, ok := toSlice(lhs); ok {
+		rhsItems, ok := toSlice(rhs)
+		if !ok || len(lhsItems) != len(rhsItems) {
+			return false
+		}
+		for i := range lhsItems {
+			if !valuesEqual(lhsItems[i], rhsItems[i], coercion) {
+				return false
+			}
+		}
+		return true
+	}
+
+	lhsMap, lhsIsMap := lhs.(map[string]interface{})
+	rhsMap, rhsIsMap := rhs.(map[string]interface{})
+	if lhsIsMap && rhsIsMap {
+		if len(lhsMap) != len(rhsMap) {
+			return false
+		}
+		for key, lhsValue := range lhsMap {
+			rhsValue, exists := rhsMap[key]
+			if !exists || !valuesEqual(lhsValue, rhsValue, coercion) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(lhs, rhs)
+}
+
+// orderValues returns -1, 0 or 1 comparing two numbers, two dates, or two strings lexically.
+func orderValues(lhs interface{}, rhs interface{}, operator string, coercion CoercionPolicy) (int, error) {
+	lhs, rhs = coerceOperands(lhs, rhs, coercion)
+
+	lhsFloat, lhsIsNum := lhs.(float64)
+	rhsFloat, rhsIsNum := rhs.(float64)
+	if lhsIsNum && rhsIsNum {
+		switch {
+		case lhsFloat < rhsFloat:
+			return -1, nil
+		case lhsFloat > rhsFloat:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	}
+
+	if order, ok := orderTimes(lhs, rhs, coercion); ok {
+		return order, nil
+	}
+
+	lhsStr, lhsIsStr := lhs.(string)
+	rhsStr, rhsIsStr := rhs.(string)
+	if lhsIsStr && rhsIsStr {
+		return strings.Compare(lhsStr, rhsStr), nil
+	}
+
+	return 0, fmt.Errorf("operator %s requires two numbers, dates or strings, got %s and %s", operator, describeType(lhs), describeType(rhs))
+}
+
+func checkOrder(order int, operator string) bool {
+	switch operator {
+	case "==":
+		return order == 0
+	case "!=":
+		return order != 0
+	case ">":
+		return order > 0
+	case "<":
+		return order < 0
+	case ">=":
+		return order >= 0
+	default:
+		return order <= 0
+	}
+}
+
+// toFloat64 converts any Go, JSON or BSON numeric type to float64.
+func toFloat64(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int8:
+		return float64(val), true
+	case int16:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case uint:
+		return float64(val), true
+	case uint8:
+		return float64(val), true
+	case uint16:
+		return float64(val), true
+	case uint32:
+		return float64(val), true
+	case uint64:
+		return float64(val), true
+	case json.Number:
+		number, err := val.Float64()
+		return number, err == nil
+	case primitive.Decimal128:
+		number, err := strconv.ParseFloat(val.String(), 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// toSlice converts any array value (including BSON arrays) to []interface{}.
+func toSlice(value interface{}) ([]interface{}, bool) {
+	switch val := value.(type) {
+	case []interface{}:
+		return val, true
+	case nil, string:
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, true
+}
+
+func sliceContains(items []interface{}, value interface{}, coercion CoercionPolicy) bool {
+	for _, item := range items {
+		if valuesEqual(item, value, coercion) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueLength returns the length of a string (in characters), array or document.
+func valueLength(value interface{}) (int, bool) {
+	if str, ok := value.(string); ok {
+		return utf8.RuneCountInString(str), true
+	}
+	if value == nil {
+		return 0, false
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len(), true
+	}
+	return 0, false
+}
+
+// isEmptyValue reports whether a value is null (including BSON null), an
+// empty string, array or document.
+func isEmptyValue(value interface{}) bool {
+	if normalizeValue(value) == nil {
+		return true
+	}
+	length, ok := valueLength(value)
+	return ok && length == 0
+}
+
+// compilePattern compiles the regular expression of a matches operator.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
@@ -0,0 +1,330 @@
+package nodes
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+// RuleAction is one step of a rule's then list: set Fact to Value (resolved
+// as a template) or to the result of Expression, or delete Fact.
+type RuleAction struct {
+	Fact       string
+	Value      interface{}
+	Expression *Expression
+	Delete     bool
+	// Resolver holds the parsed call placeholders of Value
+	Resolver Resolver
+}
+
+type Rule struct {
+	Name     string
+	Salience float64
+	When     *Expression
+	Then     []RuleAction
+}
+
+// RulesetNode runs forward-chaining rules against a working copy of the
+// context. Each cycle fires the eligible rule with the highest salience
+// (table order breaks ties) until no rule is eligible. A rule is eligible
+// when its condition holds and the facts its condition reads have changed
+// since it last fired, so a rule never fires twice on the same facts.
+type RulesetNode struct {
+	ID        string
+	Rules     []Rule
+	MaxCycles int
+	OutputKey string
+}
+
+func NewRulesetNode(def workflow.NodeDefinition) (*RulesetNode, error) {
+	rulesValue, ok := def.Config["rules"].([]interface{})
+	if !ok || len(rulesValue) == 0 {
+		return nil, fmt.Errorf("rules must be a non-empty array")
+	}
+
+	coercion, err := coercionFromConfig(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, 0, len(rulesValue))
+	names := make(map[string]bool)
+	for i, ruleValue := range rulesValue {
+		rule, err := parseRule(ruleValue, coercion)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name: %s", rule.Name)
+		}
+		names[rule.Name] = true
+		rules = append(rules, rule)
+	}
+
+	// Highest salience first, keeping table order for equal salience
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Salience > rules[j].Salience
+	})
+
+	maxCycles := 100 // default
+	if maxValue, exists := def.Config["maxCycles"]; exists {
+		maxFloat, ok := maxValue.(float64)
+		if !ok || maxFloat < 1 {
+			return nil, fmt.Errorf("maxCycles must be a positive number")
+		}
+		maxCycles = int(maxFloat)
+	}
+
+	outputKey := "firedRules" // default
+	if keyValue, exists := def.Config["outputKey"]; exists {
+		if key, ok := keyValue.(string); ok {
+			outputKey = key
+		}
+	}
+
+	return &RulesetNode{
+		ID:        def.ID,
+		Rules:     rules,
+		MaxCycles: maxCycles,
+		OutputKey: outputKey,
+	}, nil
+}
+
+func parseRule(value interface{}, coercion CoercionPolicy) (Rule, error) {
+	ruleMap, ok := value.(map[string]interface{})
+	if !ok {
+		return Rule{}, fmt.Errorf("must be an object")
+	}
+
+	var rule Rule
+	if nameValue, exists := ruleMap["name"]; exists {
+		if rule.Name, ok = nameValue.(string); !ok {
+			return rule, fmt.Errorf("name must be a string")
+		}
+	}
+	if salienceValue, exists := ruleMap["salience"]; exists {
+		if rule.Salience, ok = salienceValue.(float64); !ok {
+			return rule, fmt.Errorf("salience must be a number")
+		}
+	}
+
+	when, ok := ruleMap["when"].(string)
+	if !ok {
+		return rule, fmt.Errorf("when must be an expression string")
+	}
+	expression, err := ParseExpression(when)
+	if err != nil {
+		return rule, err
+	}
+	rule.When = expression.WithCoercion(coercion)
+
+	thenValue, ok := ruleMap["then"].([]interface{})
+	if !ok || len(thenValue) == 0 {
+		return rule, fmt.Errorf("then must be a non-empty array of actions")
+	}
+	for i, actionValue := range thenValue {
+		action, err := parseRuleAction(actionValue, coercion)
+		if err != nil {
+			return rule, fmt.Errorf("action %d: %w", i+1, err)
+		}
+		rule.Then = append(rule.Then, action)
+	}
+
+	return rule, nil
+}
+
+func parseRuleAction(value interface{}, coercion CoercionPolicy) (RuleAction, error) {
+	actionMap, ok := value.(map[string]interface{})
+	if !ok {
+		return RuleAction{}, fmt.Errorf("must be an object")
+	}
+
+	if factValue, exists := actionMap["delete"]; exists {
+		fact, ok := factValue.(string)
+		if !ok || fact == "" {
+			return RuleAction{}, fmt.Errorf("delete must be a fact name")
+		}
+		if err := validateFactName(fact); err != nil {
+			return RuleAction{}, err
+		}
+		return RuleAction{Fact: fact, Delete: true}, nil
+	}
+
+	fact, ok := actionMap["set"].(string)
+	if !ok || fact == "" {
+		return RuleAction{}, fmt.Errorf("set or delete must name a fact")
+	}
+	if err := validateFactName(fact); err != nil {
+		return RuleAction{}, err
+	}
+	action := RuleAction{Fact: fact}
+
+	value, hasValue := actionMap["value"]
+	expressionValue, hasExpression := actionMap["expression"]
+	switch {
+	case hasValue && hasExpression:
+		return action, fmt.Errorf("set takes either value or expression, not both")
+	case hasExpression:
+		source, ok := expressionValue.(string)
+		if !ok {
+			return action, fmt.Errorf("expression must be a string")
+		}
+		expression, err := ParseExpression(source)
+		if err != nil {
+			return action, err
+		}
+		action.Expression = expression.WithCoercion(coercion)
+	case hasValue:
+		if err := action.Resolver.Prepare(value); err != nil {
+			return action, err
+		}
+		action.Value = value
+	default:
+		return action, fmt.Errorf("set requires a value or an expression")
+	}
+	return action, nil
+}
+
+// validateFactName rejects facts rules cannot write: the reserved node
+// records, and dotted names, which would become a flat key rather than a
+// field of an object.
+func validateFactName(fact string) error {
+	if fact == workflow.NodesKey {
+		return fmt.Errorf("fact %s is reserved for node records", fact)
+	}
+	if strings.Contains(fact, ".") {
+		return fmt.Errorf("fact %s: fact names cannot contain dots, set the whole object instead", fact)
+	}
+	return nil
+}
+
+func (n *RulesetNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	facts := make(map[string]interface{}, len(ctx))
+	for key, value := range ctx {
+		facts[key] = value
+	}
+
+	firedOn := make(map[string][]interface{}) // facts each rule read when it last fired
+	var fired []interface{}
+
+	for {
+		rule, activation, err := n.nextRule(facts, firedOn)
+		if err != nil {
+			return workflow.NodeResult{}, err
+		}
+		if rule == nil {
+			break
+		}
+		if len(fired) == n.MaxCycles {
+			return workflow.NodeResult{}, fmt.Errorf("ruleset did not settle after %d cycles, last rule ready to fire: %s", n.MaxCycles, rule.Name)
+		}
+
+		if err := applyRuleActions(rule, facts); err != nil {
+			return workflow.NodeResult{}, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		firedOn[rule.Name] = activation
+		fired = append(fired, rule.Name)
+	}
+
+	data, deleted := changedFacts(ctx, facts)
+	delete(data, workflow.NodesKey)
+	data[n.OutputKey] = fired
+
+	return workflow.NodeResult{
+		Output:  "default",
+		Data:    data,
+		Deleted: deleted,
+	}, nil
+}
+
+// missingFact marks a fact that was absent when a rule fired.
+type missingFact struct{}
+
+// nextRule returns the eligible rule with the highest salience and the facts
+// its condition read, or nil. A condition that refers to a missing fact does
+// not hold.
+func (n *RulesetNode) nextRule(facts map[string]interface{}, firedOn map[string][]interface{}) (*Rule, []interface{}, error) {
+	for i := range n.Rules {
+		rule := &n.Rules[i]
+
+		paths := rule.When.Variables()
+		activation := make([]interface{}, len(paths))
+		for j, path := range paths {
+			value, exists := lookupVariable(path, facts)
+			if _, builtin := builtinVariable(path); !exists || builtin {
+				// Built-ins such as now change on every read and are not facts
+				value = missingFact{}
+			}
+			activation[j] = value
+		}
+		if last, exists := firedOn[rule.Name]; exists && reflect.DeepEqual(last, activation) {
+			continue
+		}
+
+		holds, err := rule.When.EvaluateBool(facts)
+		if err != nil {
+			var missing *MissingVariableError
+			if errors.As(err, &missing) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		if holds {
+			return rule, activation, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// applyRuleActions runs a rule's actions in order against the facts.
+func applyRuleActions(rule *Rule, facts map[string]interface{}) error {
+	for _, action := range rule.Then {
+		if action.Delete {
+			delete(facts, action.Fact)
+			continue
+		}
+
+		var value interface{}
+		var err error
+		if action.Expression != nil {
+			value, err = action.Expression.Evaluate(facts)
+		} else {
+			value, err = action.Resolver.Resolve(action.Value, facts)
+		}
+		if err != nil {
+			return fmt.Errorf("set %s: %w", action.Fact, err)
+		}
+		facts[action.Fact] = value
+	}
+	return nil
+}
+
+// changedFacts returns the facts the rules added or modified and, sorted,
+// the facts they deleted.
+func changedFacts(before map[string]interface{}, after map[string]interface{}) (map[string]interface{}, []string) {
+	data := make(map[string]interface{})
+	for key, value := range after {
+		if old, existed := before[key]; !existed || !reflect.DeepEqual(old, value) {
+			data[key] = value
+		}
+	}
+	var deleted []string
+	for key := range before {
+		if _, exists := after[key]; !exists {
+			deleted = append(deleted, key)
+		}
+	}
+	sort.Strings(deleted)
+	return data, deleted
+}
+
+func (n *RulesetNode) Outputs() []string {
+	return []string{"default"}
+}
@@ -0,0 +1,247 @@
+package nodes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+func newRuleset(config map[string]interface{}) (*RulesetNode, error) {
+	return NewRulesetNode(workflow.NodeDefinition{ID: "rules", Type: "ruleset", Config: config})
+}
+
+// setRule builds a rule that sets fact to the result of expression.
+func setRule(name string, when string, fact string, expression string) map[string]interface{} {
+	return map[string]interface{}{
+		"name": name,
+		"when": when,
+		"then": []interface{}{map[string]interface{}{"set": fact, "expression": expression}},
+	}
+}
+
+func withSalience(rule map[string]interface{}, salience float64) map[string]interface{} {
+	rule["salience"] = salience
+	return rule
+}
+
+func TestRulesetBuildErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		err    string
+	}{
+		{
+			name:   "no rules",
+			config: map[string]interface{}{"rules": []interface{}{}},
+			err:    "rules must be a non-empty array",
+		},
+		{
+			name:   "set the reserved nodes key",
+			config: map[string]interface{}{"rules": []interface{}{setRule("r", "true", "nodes", "1")}},
+			err:    "rule 1: action 1: fact nodes is reserved for node records",
+		},
+		{
+			name: "delete the reserved nodes key",
+			config: map[string]interface{}{"rules": []interface{}{map[string]interface{}{
+				"when": "true",
+				"then": []interface{}{map[string]interface{}{"delete": "nodes"}},
+			}}},
+			err: "rule 1: action 1: fact nodes is reserved for node records",
+		},
+		{
+			name:   "set a dotted fact",
+			config: map[string]interface{}{"rules": []interface{}{setRule("r", "true", "order.total", "1")}},
+			err:    "rule 1: action 1: fact order.total: fact names cannot contain dots, set the whole object instead",
+		},
+		{
+			name: "delete a dotted fact",
+			config: map[string]interface{}{"rules": []interface{}{map[string]interface{}{
+				"when": "true",
+				"then": []interface{}{map[string]interface{}{"delete": "order.coupon"}},
+			}}},
+			err: "rule 1: action 1: fact order.coupon: fact names cannot contain dots",
+		},
+		{
+			name: "value and expression",
+			config: map[string]interface{}{"rules": []interface{}{map[string]interface{}{
+				"when": "true",
+				"then": []interface{}{map[string]interface{}{"set": "x", "value": 1.0, "expression": "2"}},
+			}}},
+			err: "rule 1: action 1: set takes either value or expression, not both",
+		},
+		{
+			name: "duplicate rule names",
+			config: map[string]interface{}{"rules": []interface{}{
+				setRule("same", "true", "x", "1"),
+				setRule("same", "true", "y", "2"),
+			}},
+			err: "duplicate rule name: same",
+		},
+		{
+			name: "unnamed rule clashing with a default name",
+			config: map[string]interface{}{"rules": []interface{}{
+				setRule("rule-2", "true", "x", "1"),
+				map[string]interface{}{"when": "true", "then": []interface{}{map[string]interface{}{"delete": "x"}}},
+			}},
+			err: "duplicate rule name: rule-2",
+		},
+		{
+			name:   "invalid when",
+			config: map[string]interface{}{"rules": []interface{}{setRule("r", "x >", "y", "1")}},
+			err:    "rule 1:",
+		},
+		{
+			name:   "zero maxCycles",
+			config: map[string]interface{}{"rules": []interface{}{setRule("r", "true", "x", "1")}, "maxCycles": 0.0},
+			err:    "maxCycles must be a positive number",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newRuleset(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("NewRulesetNode() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRulesetExecute(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     []interface{}
+		maxCycles float64
+		ctx       map[string]interface{}
+		want      map[string]interface{}
+		deleted   []string
+		err       string
+	}{
+		{
+			name: "higher salience fires first",
+			rules: []interface{}{
+				setRule("basic", "orders > 0", "tier", `"basic"`),
+				withSalience(setRule("gold", "orders > 0", "tier", `"gold"`), 10),
+			},
+			ctx:  map[string]interface{}{"orders": 3.0},
+			want: map[string]interface{}{"tier": "basic", "firedRules": []interface{}{"gold", "basic"}},
+		},
+		{
+			name: "equal salience keeps table order",
+			rules: []interface{}{
+				setRule("first", "orders > 0", "tier", `"first"`),
+				setRule("second", "orders > 0", "tier", `"second"`),
+			},
+			ctx:  map[string]interface{}{"orders": 3.0},
+			want: map[string]interface{}{"tier": "second", "firedRules": []interface{}{"first", "second"}},
+		},
+		{
+			name:  "rule fires again when the facts it reads change",
+			rules: []interface{}{setRule("count", "count < 5", "count", "count + 1")},
+			ctx:   map[string]interface{}{"count": 0.0},
+			want: map[string]interface{}{
+				"count":      5.0,
+				"firedRules": []interface{}{"count", "count", "count", "count", "count"},
+			},
+		},
+		{
+			name: "rule does not fire twice on the same facts",
+			rules: []interface{}{
+				setRule("flag", "orders > 0", "seen", "true"),
+				setRule("unrelated", "seen", "note", `"done"`),
+			},
+			ctx: map[string]interface{}{"orders": 1.0},
+			want: map[string]interface{}{
+				"seen":       true,
+				"note":       "done",
+				"firedRules": []interface{}{"flag", "unrelated"},
+			},
+		},
+		{
+			name: "rules chain on facts set by other rules",
+			rules: []interface{}{
+				setRule("total", "exists(discount)", "total", "price * (1 - discount)"),
+				setRule("bulk", "quantity >= 100", "discount", "0.5"),
+			},
+			ctx: map[string]interface{}{"quantity": 100.0, "price": 10.0},
+			want: map[string]interface{}{
+				"discount":   0.5,
+				"total":      5.0,
+				"firedRules": []interface{}{"bulk", "total"},
+			},
+		},
+		{
+			name:  "missing fact does not fire",
+			rules: []interface{}{setRule("vip", "points > 100", "vip", "true")},
+			ctx:   map[string]interface{}{},
+			want:  map[string]interface{}{"firedRules": []interface{}(nil)},
+		},
+		{
+			name: "deleted facts are returned as deletions",
+			rules: []interface{}{map[string]interface{}{
+				"name": "drop-coupons",
+				"when": "exists(coupon)",
+				"then": []interface{}{
+					map[string]interface{}{"delete": "coupon"},
+					map[string]interface{}{"delete": "bonus"},
+				},
+			}},
+			ctx:     map[string]interface{}{"coupon": "SAVE10", "bonus": 5.0},
+			want:    map[string]interface{}{"firedRules": []interface{}{"drop-coupons"}},
+			deleted: []string{"bonus", "coupon"},
+		},
+		{
+			name:  "node records are read but not returned",
+			rules: []interface{}{setRule("copy", "nodes.lookup.found", "found", "true")},
+			ctx: map[string]interface{}{
+				workflow.NodesKey: map[string]interface{}{"lookup": map[string]interface{}{"found": true}},
+			},
+			want: map[string]interface{}{"found": true, "firedRules": []interface{}{"copy"}},
+		},
+		{
+			name:      "maxCycles",
+			rules:     []interface{}{setRule("grow", "count >= 0", "count", "count + 1")},
+			maxCycles: 3,
+			ctx:       map[string]interface{}{"count": 0.0},
+			err:       "ruleset did not settle after 3 cycles, last rule ready to fire: grow",
+		},
+		{
+			name:  "evaluation error",
+			rules: []interface{}{setRule("bad", `name > 3`, "x", "1")},
+			ctx:   map[string]interface{}{"name": "ada"},
+			err:   "rule bad:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]interface{}{"rules": tt.rules}
+			if tt.maxCycles != 0 {
+				config["maxCycles"] = tt.maxCycles
+			}
+			node, err := newRuleset(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := node.Execute(tt.ctx)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result.Output != "default" || !reflect.DeepEqual(result.Data, tt.want) {
+				t.Errorf("Execute() = %s %v, want %v", result.Output, result.Data, tt.want)
+			}
+			if !reflect.DeepEqual(result.Deleted, tt.deleted) {
+				t.Errorf("Execute() deleted %v, want %v", result.Deleted, tt.deleted)
+			}
+		})
+	}
+}
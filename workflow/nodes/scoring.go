@@ -0,0 +1,194 @@
+package nodes
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+// ScoringRule adds Score when Condition holds. The condition takes the same
+// lhs/operator/rhs or expression config as a condition node.
+type ScoringRule struct {
+	Name      string
+	Condition *ConditionNode
+	Score     float64
+}
+
+// ScoreBand is emitted as the output when the total score is at least Min.
+type ScoreBand struct {
+	Min    float64
+	Output string
+}
+
+// ScoringNode sums the scores of every matching rule and routes on the band
+// the total falls into.
+type ScoringNode struct {
+	ID        string
+	Rules     []ScoringRule
+	Bands     []ScoreBand // highest Min first
+	OutputKey string
+}
+
+func NewScoringNode(def workflow.NodeDefinition) (*ScoringNode, error) {
+	rulesValue, ok := def.Config["rules"].([]interface{})
+	if !ok || len(rulesValue) == 0 {
+		return nil, fmt.Errorf("rules must be a non-empty array")
+	}
+
+	rules := make([]ScoringRule, 0, len(rulesValue))
+	for i, ruleValue := range rulesValue {
+		ruleMap, ok := ruleValue.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("rule %d must be an object", i+1)
+		}
+
+		name := fmt.Sprintf("rule-%d", i+1)
+		if nameValue, exists := ruleMap["name"]; exists {
+			if name, ok = nameValue.(string); !ok {
+				return nil, fmt.Errorf("rule %d: name must be a string", i+1)
+			}
+		}
+
+		score, ok := ruleMap["score"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("rule %s: score must be a number", name)
+		}
+
+		// Rules inherit the node's coercion and onMissing policies unless they set their own
+		conditionConfig := make(map[string]interface{}, len(ruleMap)+2)
+		for _, key := range []string{"coercion", "onMissing"} {
+			if value, exists := def.Config[key]; exists {
+				conditionConfig[key] = value
+			}
+		}
+		for key, value := range ruleMap {
+			conditionConfig[key] = value
+		}
+
+		condition, err := NewConditionNode(workflow.NodeDefinition{ID: name, Type: "condition", Config: conditionConfig})
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		rules = append(rules, ScoringRule{Name: name, Condition: condition, Score: score})
+	}
+
+	var bands []ScoreBand
+	if thresholdsValue, exists := def.Config["thresholds"]; exists {
+		thresholds, ok := thresholdsValue.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("thresholds must be an array")
+		}
+		for i, thresholdValue := range thresholds {
+			band, err := parseScoreBand(thresholdValue)
+			if err != nil {
+				return nil, fmt.Errorf("threshold %d: %w", i+1, err)
+			}
+			for _, existing := range bands {
+				if existing.Min == band.Min {
+					return nil, fmt.Errorf("thresholds %s and %s have the same min", existing.Output, band.Output)
+				}
+			}
+			bands = append(bands, band)
+		}
+		sort.Slice(bands, func(i, j int) bool {
+			return bands[i].Min > bands[j].Min
+		})
+	}
+
+	outputKey := "score" // default
+	if keyValue, exists := def.Config["outputKey"]; exists {
+		if key, ok := keyValue.(string); ok {
+			outputKey = key
+		}
+	}
+
+	return &ScoringNode{
+		ID:        def.ID,
+		Rules:     rules,
+		Bands:     bands,
+		OutputKey: outputKey,
+	}, nil
+}
+
+// parseScoreBand reads {"min": 30, "output": "medium"}; a band without min
+// catches every score below the other bands.
+func parseScoreBand(value interface{}) (ScoreBand, error) {
+	bandMap, ok := value.(map[string]interface{})
+	if !ok {
+		return ScoreBand{}, fmt.Errorf("must be an object")
+	}
+
+	output, ok := bandMap["output"].(string)
+	if !ok || output == "" {
+		return ScoreBand{}, fmt.Errorf("output must be a non-empty string")
+	}
+
+	band := ScoreBand{Min: math.Inf(-1), Output: output}
+	if minValue, exists := bandMap["min"]; exists {
+		if band.Min, ok = minValue.(float64); !ok {
+			return band, fmt.Errorf("min must be a number")
+		}
+	}
+	return band, nil
+}
+
+func (n *ScoringNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	total := 0.0
+	contributed := []interface{}{}
+
+	for _, rule := range n.Rules {
+		output, err := rule.Condition.check(ctx)
+		if err != nil {
+			return workflow.NodeResult{}, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		if output != "true" {
+			continue
+		}
+		total += rule.Score
+		contributed = append(contributed, map[string]interface{}{
+			"name":  rule.Name,
+			"score": rule.Score,
+		})
+	}
+
+	output := "default"
+	matched := false
+	for _, band := range n.Bands {
+		if total >= band.Min {
+			output = band.Output
+			matched = true
+			break
+		}
+	}
+
+	data := map[string]interface{}{
+		n.OutputKey:           total,
+		n.OutputKey + "Rules": contributed,
+	}
+	if matched {
+		data[n.OutputKey+"Band"] = output
+	}
+
+	return workflow.NodeResult{
+		Output: output,
+		Data:   data,
+	}, nil
+}
+
+func (n *ScoringNode) Outputs() []string {
+	outputs := make([]string, 0, len(n.Bands)+1)
+	for _, band := range n.Bands {
+		if !slices.Contains(outputs, band.Output) {
+			outputs = append(outputs, band.Output)
+		}
+	}
+	// Scores below every band emit default unless a band has no min
+	catchAll := len(n.Bands) > 0 && math.IsInf(n.Bands[len(n.Bands)-1].Min, -1)
+	if !catchAll && !slices.Contains(outputs, "default") {
+		outputs = append(outputs, "default")
+	}
+	return outputs
+}
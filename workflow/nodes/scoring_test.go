@@ -0,0 +1,91 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+func TestScoringBands(t *testing.T) {
+	thresholds := []interface{}{
+		map[string]interface{}{"min": 10.0, "output": "default"},
+		map[string]interface{}{"min": 50.0, "output": "high"},
+	}
+	node, err := NewScoringNode(workflow.NodeDefinition{ID: "score", Type: "scoring", Config: map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"name": "young", "lhs": "{{age}}", "operator": "<", "rhs": 25.0, "score": 20.0},
+			map[string]interface{}{"name": "foreign", "expression": "country != \"USA\"", "score": 40.0},
+			// Matching rules are listed even when they add no points
+			map[string]interface{}{"name": "verified", "expression": "verified", "score": 0.0},
+			map[string]interface{}{"name": "vip", "expression": "vip", "score": -15.0},
+		},
+		"thresholds": thresholds,
+		"outputKey":  "risk",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule := func(name string, score float64) interface{} {
+		return map[string]interface{}{"name": name, "score": score}
+	}
+	tests := []struct {
+		name    string
+		ctx     map[string]interface{}
+		output  string
+		band    interface{}
+		hasBand bool
+		total   float64
+		rules   []interface{}
+	}{
+		{
+			name:    "band named default",
+			ctx:     map[string]interface{}{"age": 20.0, "country": "USA", "verified": true, "vip": false},
+			output:  "default",
+			band:    "default",
+			hasBand: true,
+			total:   20,
+			rules:   []interface{}{rule("young", 20), rule("verified", 0)},
+		},
+		{
+			name:    "highest band",
+			ctx:     map[string]interface{}{"age": 20.0, "country": "FRA", "verified": false, "vip": false},
+			output:  "high",
+			band:    "high",
+			hasBand: true,
+			total:   60,
+			rules:   []interface{}{rule("young", 20), rule("foreign", 40)},
+		},
+		{
+			name:    "negative points",
+			ctx:     map[string]interface{}{"age": 20.0, "country": "FRA", "verified": true, "vip": true},
+			output:  "default",
+			band:    "default",
+			hasBand: true,
+			total:   45,
+			rules:   []interface{}{rule("young", 20), rule("foreign", 40), rule("verified", 0), rule("vip", -15)},
+		},
+		{
+			name:   "below every band",
+			ctx:    map[string]interface{}{"age": 40.0, "country": "USA", "verified": false, "vip": false},
+			output: "default",
+			rules:  []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := node.Execute(tt.ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			band, hasBand := result.Data["riskBand"]
+			if result.Output != tt.output || hasBand != tt.hasBand || band != tt.band || result.Data["risk"] != tt.total {
+				t.Errorf("Execute() = %s, risk %v, riskBand %v (set %v)", result.Output, result.Data["risk"], band, hasBand)
+			}
+			if !reflect.DeepEqual(result.Data["riskRules"], tt.rules) {
+				t.Errorf("riskRules = %v, want %v", result.Data["riskRules"], tt.rules)
+			}
+		})
+	}
+}
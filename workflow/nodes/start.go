@@ -21,6 +21,9 @@ func NewStartNode(def workflow.NodeDefinition) (*StartNode, error) {
 	if !ok {
 		return nil, fmt.Errorf("initialData must be an object")
 	}
+	if _, reserved := dataMap[workflow.NodesKey]; reserved {
+		return nil, fmt.Errorf("initialData cannot set %q, it is reserved for node records", workflow.NodesKey)
+	}
 	var newNode StartNode = StartNode{
 		ID:          def.ID,
 		InitialData: dataMap,
@@ -38,3 +41,7 @@ func (n *StartNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, er
 
 	return result, nil
 }
+
+func (n *StartNode) Outputs() []string {
+	return []string{"default"}
+}
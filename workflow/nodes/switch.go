@@ -0,0 +1,100 @@
+package nodes
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+// SwitchCase routes to Output when Expression evaluates to true.
+type SwitchCase struct {
+	Expression *Expression
+	Output     string
+}
+
+// SwitchNode evaluates its cases in order and emits the output label of the
+// first one that matches, or Default when none do.
+type SwitchNode struct {
+	ID      string
+	Cases   []SwitchCase
+	Default string
+}
+
+func NewSwitchNode(def workflow.NodeDefinition) (*SwitchNode, error) {
+	coercion, err := coercionFromConfig(def.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	casesValue, ok := def.Config["cases"].([]interface{})
+	if !ok || len(casesValue) == 0 {
+		return nil, fmt.Errorf("cases must be a non-empty array")
+	}
+
+	cases := make([]SwitchCase, 0, len(casesValue))
+	for i, caseValue := range casesValue {
+		caseMap, ok := caseValue.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("case %d must be an object", i)
+		}
+
+		expressionStr, ok := caseMap["expression"].(string)
+		if !ok {
+			return nil, fmt.Errorf("case %d: expression must be a string", i)
+		}
+		output, ok := caseMap["output"].(string)
+		if !ok || output == "" {
+			return nil, fmt.Errorf("case %d: output must be a non-empty string", i)
+		}
+		if slices.ContainsFunc(cases, func(existing SwitchCase) bool { return existing.Output == output }) {
+			return nil, fmt.Errorf("case %d: duplicate output %s, combine the expressions with || instead", i, output)
+		}
+
+		expression, err := ParseExpression(expressionStr)
+		if err != nil {
+			return nil, fmt.Errorf("case %d: %w", i, err)
+		}
+		cases = append(cases, SwitchCase{Expression: expression.WithCoercion(coercion), Output: output})
+	}
+
+	defaultOutput := "default"
+	if defaultValue, exists := def.Config["default"]; exists {
+		defaultStr, ok := defaultValue.(string)
+		if !ok || defaultStr == "" {
+			return nil, fmt.Errorf("default must be a non-empty string")
+		}
+		defaultOutput = defaultStr
+	}
+
+	return &SwitchNode{
+		ID:      def.ID,
+		Cases:   cases,
+		Default: defaultOutput,
+	}, nil
+}
+
+func (n *SwitchNode) Execute(ctx map[string]interface{}) (workflow.NodeResult, error) {
+	for i, switchCase := range n.Cases {
+		matched, err := switchCase.Expression.EvaluateBool(ctx)
+		if err != nil {
+			return workflow.NodeResult{}, fmt.Errorf("case %d: %w", i, err)
+		}
+		if matched {
+			return workflow.NodeResult{Output: switchCase.Output}, nil
+		}
+	}
+
+	return workflow.NodeResult{Output: n.Default}, nil
+}
+
+func (n *SwitchNode) Outputs() []string {
+	outputs := make([]string, 0, len(n.Cases)+1)
+	for _, switchCase := range n.Cases {
+		outputs = append(outputs, switchCase.Output)
+	}
+	if !slices.Contains(outputs, n.Default) {
+		outputs = append(outputs, n.Default)
+	}
+	return outputs
+}
@@ -0,0 +1,142 @@
+package nodes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+)
+
+func newSwitch(config map[string]interface{}) (*SwitchNode, error) {
+	return NewSwitchNode(workflow.NodeDefinition{ID: "route", Type: "switch", Config: config})
+}
+
+func switchCase(expression string, output string) map[string]interface{} {
+	return map[string]interface{}{"expression": expression, "output": output}
+}
+
+func TestSwitchRoutes(t *testing.T) {
+	tiers := []interface{}{
+		switchCase("spend >= 10000", "platinum"),
+		switchCase("spend >= 5000", "gold"),
+		switchCase(`country in ["USA", "CAN"]`, "domestic"),
+	}
+
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		ctx    map[string]interface{}
+		output string
+		err    string
+	}{
+		{
+			name:   "first matching case wins",
+			config: map[string]interface{}{"cases": tiers},
+			ctx:    map[string]interface{}{"spend": 12000.0, "country": "USA"},
+			output: "platinum",
+		},
+		{
+			name:   "later case",
+			config: map[string]interface{}{"cases": tiers},
+			ctx:    map[string]interface{}{"spend": 6000.0, "country": "USA"},
+			output: "gold",
+		},
+		{
+			name:   "last case",
+			config: map[string]interface{}{"cases": tiers},
+			ctx:    map[string]interface{}{"spend": 100.0, "country": "CAN"},
+			output: "domestic",
+		},
+		{
+			name:   "no case falls back to default",
+			config: map[string]interface{}{"cases": tiers},
+			ctx:    map[string]interface{}{"spend": 100.0, "country": "FRA"},
+			output: "default",
+		},
+		{
+			name:   "no case falls back to a named default",
+			config: map[string]interface{}{"cases": tiers, "default": "standard"},
+			ctx:    map[string]interface{}{"spend": 100.0, "country": "FRA"},
+			output: "standard",
+		},
+		{
+			name:   "expression error",
+			config: map[string]interface{}{"cases": tiers},
+			ctx:    map[string]interface{}{"spend": "a lot", "country": "USA"},
+			err:    "case 0:",
+		},
+		{
+			name:   "expression error after a case that did not match",
+			config: map[string]interface{}{"cases": tiers},
+			ctx:    map[string]interface{}{"spend": 100.0},
+			err:    "case 2:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := newSwitch(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := node.Execute(tt.ctx)
+			if tt.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result.Output != tt.output {
+				t.Errorf("Execute() = %s, want %s", result.Output, tt.output)
+			}
+		})
+	}
+}
+
+func TestSwitchBuildErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		err    string
+	}{
+		{"no cases", map[string]interface{}{"cases": []interface{}{}}, "cases must be a non-empty array"},
+		{"case without output", map[string]interface{}{"cases": []interface{}{switchCase("x > 1", "")}}, "case 0: output must be a non-empty string"},
+		{"invalid expression", map[string]interface{}{"cases": []interface{}{switchCase("x >", "big")}}, "case 0:"},
+		{
+			name: "duplicate case outputs",
+			config: map[string]interface{}{"cases": []interface{}{
+				switchCase("x > 10", "big"),
+				switchCase("x < 0", "negative"),
+				switchCase("y > 10", "big"),
+			}},
+			err: "case 2: duplicate output big",
+		},
+		{"empty default", map[string]interface{}{"cases": []interface{}{switchCase("x > 1", "big")}, "default": ""}, "default must be a non-empty string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newSwitch(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("NewSwitchNode() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSwitchOutputs(t *testing.T) {
+	node, err := newSwitch(map[string]interface{}{
+		"cases":   []interface{}{switchCase("x > 10", "big"), switchCase("x < 0", "negative")},
+		"default": "big",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := node.Outputs(), []string{"big", "negative"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Outputs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,459 @@
+package nodes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// templateVariable returns the variable path of a "{{path}}" template.
+func templateVariable(template string) (string, bool) {
+	if !strings.HasPrefix(template, "{{") || !strings.HasSuffix(template, "}}") {
+		return "", false
+	}
+	varName := strings.TrimPrefix(template, "{{")
+	varName = strings.TrimSuffix(varName, "}}")
+	return strings.TrimSpace(varName), true
+}
+
+// MissingVariablePolicy controls what template resolution does with a
+// variable that is not in the context.
+type MissingVariablePolicy string
+
+const (
+	// MissingError fails resolution (default)
+	MissingError MissingVariablePolicy = "error"
+	// MissingEmpty substitutes an empty string
+	MissingEmpty MissingVariablePolicy = "empty"
+	// MissingKeep leaves the placeholder text as-is
+	MissingKeep MissingVariablePolicy = "keep"
+)
+
+// Resolver substitutes "{{path}}" placeholders in node configuration values.
+// A string that is exactly one placeholder resolves to the referenced value
+// with its type preserved; any other string has each placeholder replaced by
+// the value's text. Write \{{ or \}} for literal braces.
+type Resolver struct {
+	Missing MissingVariablePolicy
+	// expressions holds the parsed call placeholders of the templates the
+	// resolver was prepared for, keyed by placeholder content
+	expressions map[string]*Expression
+}
+
+// NewResolver reads the optional "missingVariables" setting from a node
+// config and prepares every template in the config.
+func NewResolver(config map[string]interface{}) (Resolver, error) {
+	resolver := Resolver{Missing: MissingError}
+	if missingValue, exists := config["missingVariables"]; exists {
+		missing, ok := missingValue.(string)
+		if !ok {
+			return Resolver{}, fmt.Errorf("missingVariables must be a string")
+		}
+		switch policy := MissingVariablePolicy(missing); policy {
+		case MissingError, MissingEmpty, MissingKeep:
+			resolver.Missing = policy
+		default:
+			return Resolver{}, fmt.Errorf("unknown missingVariables policy: %s", missing)
+		}
+	}
+	if err := resolver.Prepare(config); err != nil {
+		return Resolver{}, err
+	}
+	return resolver, nil
+}
+
+// Prepare parses the call placeholders, such as {{addDuration(now, "7d")}},
+// in every template of value at any depth and keeps them on the resolver.
+// Nodes prepare their templates when they are built, so a syntax error fails
+// the build instead of the run. Placeholders the resolver was not prepared
+// for are parsed each time they are resolved.
+func (r *Resolver) Prepare(value interface{}) error {
+	switch val := value.(type) {
+	case string:
+		for _, content := range placeholders(val) {
+			if _, parsed := r.expressions[content]; parsed || !strings.Contains(content, "(") {
+				continue
+			}
+			expression, err := ParseExpression(content)
+			if err != nil {
+				return err
+			}
+			if r.expressions == nil {
+				r.expressions = make(map[string]*Expression)
+			}
+			r.expressions[content] = expression
+		}
+	case map[string]interface{}:
+		for _, key := range slices.Sorted(maps.Keys(val)) {
+			if err := r.Prepare(val[key]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		return r.prepareArray(val)
+	case primitive.A:
+		return r.prepareArray(val)
+	}
+	return nil
+}
+
+func (r *Resolver) prepareArray(values []interface{}) error {
+	for _, value := range values {
+		if err := r.Prepare(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveMapValues resolves templates in data using the default resolver,
+// which fails on missing variables.
+func ResolveMapValues(data map[string]interface{}, ctx map[string]interface{}) (map[string]interface{}, error) {
+	return Resolver{}.ResolveMap(data, ctx)
+}
+
+// ResolveMap returns a copy of data with every template string, at any
+// depth and inside arrays, resolved.
+func (r Resolver) ResolveMap(data map[string]interface{}, ctx map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+
+	for key, value := range data {
+		resolved, err := r.Resolve(value, ctx)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = resolved
+	}
+
+	return result, nil
+}
+
+// Resolve resolves templates in a single configuration value.
+func (r Resolver) Resolve(value interface{}, ctx map[string]interface{}) (interface{}, error) {
+	switch val := value.(type) {
+	case string:
+		return r.ResolveString(val, ctx)
+	case map[string]interface{}:
+		// It's a nested map, resolve recursively
+		return r.ResolveMap(val, ctx)
+	case []interface{}:
+		// It's an array, resolve every element with the same rules
+		return r.resolveArray(val, ctx)
+	case primitive.A:
+		return r.resolveArray(val, ctx)
+	default:
+		// It's a number, boolean, etc - keep as-is
+		return value, nil
+	}
+}
+
+func (r Resolver) resolveArray(values []interface{}, ctx map[string]interface{}) ([]interface{}, error) {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		resolved, err := r.Resolve(value, ctx)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = resolved
+	}
+	return result, nil
+}
+
+// ResolveString resolves the placeholders in a single string.
+func (r Resolver) ResolveString(template string, ctx map[string]interface{}) (interface{}, error) {
+	if varName, isTemplate := templateVariable(template); isTemplate && !strings.Contains(varName, "}}") {
+		value, exists, err := r.resolvePlaceholder(varName, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return value, nil
+		}
+		return r.missing(varName, template)
+	}
+
+	if !strings.Contains(template, "{{") && !strings.Contains(template, `\}}`) {
+		// It's a regular string, keep as-is
+		return template, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(template); {
+		rest := template[i:]
+		switch {
+		case strings.HasPrefix(rest, `\{{`):
+			b.WriteString("{{")
+			i += 3
+		case strings.HasPrefix(rest, `\}}`):
+			b.WriteString("}}")
+			i += 3
+		case strings.HasPrefix(rest, "{{"):
+			end := strings.Index(rest, "}}")
+			if end == -1 {
+				// Unclosed placeholder, keep the rest literally
+				b.WriteString(rest)
+				i = len(template)
+				continue
+			}
+			placeholder := rest[:end+2]
+			varName := strings.TrimSpace(rest[2:end])
+			value, exists, err := r.resolvePlaceholder(varName, ctx)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				missing, err := r.missing(varName, placeholder)
+				if err != nil {
+					return nil, err
+				}
+				value = missing
+			}
+			b.WriteString(formatTemplateValue(value))
+			i += len(placeholder)
+		default:
+			b.WriteByte(template[i])
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+// placeholders returns the content of every placeholder in template, in the
+// order ResolveString resolves them.
+func placeholders(template string) []string {
+	if varName, isTemplate := templateVariable(template); isTemplate && !strings.Contains(varName, "}}") {
+		return []string{varName}
+	}
+
+	var contents []string
+	for i := 0; i < len(template); {
+		rest := template[i:]
+		switch {
+		case strings.HasPrefix(rest, `\{{`), strings.HasPrefix(rest, `\}}`):
+			i += 3
+		case strings.HasPrefix(rest, "{{"):
+			end := strings.Index(rest, "}}")
+			if end == -1 {
+				return contents
+			}
+			contents = append(contents, strings.TrimSpace(rest[2:end]))
+			i += end + 2
+		default:
+			i++
+		}
+	}
+	return contents
+}
+
+// resolvePlaceholder resolves the content of a placeholder: a variable path
+// or, when it contains a call such as {{addDuration(now, "7d")}}, an expression.
+func (r Resolver) resolvePlaceholder(content string, ctx map[string]interface{}) (interface{}, bool, error) {
+	if value, exists := lookupVariable(content, ctx); exists {
+		return value, true, nil
+	}
+	if !strings.Contains(content, "(") {
+		return nil, false, nil
+	}
+
+	expression, prepared := r.expressions[content]
+	if !prepared {
+		var err error
+		if expression, err = ParseExpression(content); err != nil {
+			return nil, false, err
+		}
+	}
+	value, err := expression.Evaluate(ctx)
+	if err != nil {
+		var missing *MissingVariableError
+		if errors.As(err, &missing) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (r Resolver) missing(varName string, placeholder string) (interface{}, error) {
+	switch r.Missing {
+	case MissingEmpty:
+		return "", nil
+	case MissingKeep:
+		return placeholder, nil
+	default:
+		return nil, &MissingVariableError{Name: varName}
+	}
+}
+
+// MissingVariableError is returned when a template or expression refers to a
+// variable that is not in the context.
+type MissingVariableError struct {
+	Name string
+}
+
+func (e *MissingVariableError) Error() string {
+	return fmt.Sprintf("variable %s not found in context", e.Name)
+}
+
+// formatTemplateValue renders a context value for interpolation into a string.
+func formatTemplateValue(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case primitive.ObjectID:
+		return val.Hex()
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case primitive.DateTime:
+		return val.Time().UTC().Format(time.RFC3339Nano)
+	case primitive.D:
+		if encoded, err := bson.MarshalExtJSON(val, false, false); err == nil {
+			return string(encoded)
+		}
+	case map[string]interface{}, primitive.M, []interface{}, primitive.A, []map[string]interface{}:
+		if encoded, err := json.Marshal(val); err == nil {
+			return string(encoded)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// lookupVariable resolves a variable path against the context. Paths walk
+// nested documents and arrays with dots and indices, so "user.address.city",
+// "similarUsers.0.email" and "similarUsers[0].email" all work, including on
+// BSON documents returned by MongoDB. Keys containing dots, such as node IDs
+// in "nodes.<nodeId>.insertedID", are matched as a whole when they exist.
+// Built-in variables such as "now" are used when the context has no match.
+func lookupVariable(name string, ctx map[string]interface{}) (interface{}, bool) {
+	if value, exists := ctx[name]; exists {
+		return value, true
+	}
+
+	segments, err := splitPath(name)
+	if err == nil && len(segments) > 0 {
+		if value, exists := walkPath(ctx, segments); exists {
+			return value, true
+		}
+	}
+	return builtinVariable(name)
+}
+
+// splitPath turns "a.b[0].c" into ["a", "b", "0", "c"].
+func splitPath(path string) ([]string, error) {
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		for part != "" {
+			open := strings.IndexByte(part, '[')
+			if open == -1 {
+				segments = append(segments, part)
+				break
+			}
+			if open > 0 {
+				segments = append(segments, part[:open])
+			}
+			end := strings.IndexByte(part[open:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed [ in path %s", path)
+			}
+			index := part[open+1 : open+end]
+			if _, err := strconv.Atoi(index); err != nil {
+				return nil, fmt.Errorf("invalid index [%s] in path %s", index, path)
+			}
+			segments = append(segments, index)
+			part = part[open+end+1:]
+		}
+	}
+	return segments, nil
+}
+
+func walkPath(value interface{}, segments []string) (interface{}, bool) {
+	if len(segments) == 0 {
+		return value, true
+	}
+
+	if isDocument(value) {
+		// Prefer the longest key so keys containing dots can be addressed
+		for i := len(segments); i > 0; i-- {
+			child, exists := documentField(value, strings.Join(segments[:i], "."))
+			if !exists {
+				continue
+			}
+			if result, ok := walkPath(child, segments[i:]); ok {
+				return result, true
+			}
+		}
+		return nil, false
+	}
+
+	index, err := strconv.Atoi(segments[0])
+	if err != nil {
+		return nil, false
+	}
+	child, exists := arrayElement(value, index)
+	if !exists {
+		return nil, false
+	}
+	return walkPath(child, segments[1:])
+}
+
+func isDocument(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, primitive.M, primitive.D:
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	return rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String
+}
+
+func documentField(value interface{}, key string) (interface{}, bool) {
+	switch doc := value.(type) {
+	case map[string]interface{}:
+		field, exists := doc[key]
+		return field, exists
+	case primitive.M:
+		field, exists := doc[key]
+		return field, exists
+	case primitive.D:
+		for _, elem := range doc {
+			if elem.Key == key {
+				return elem.Value, true
+			}
+		}
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(value)
+	field := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+	if !field.IsValid() {
+		return nil, false
+	}
+	return field.Interface(), true
+}
+
+func arrayElement(value interface{}, index int) (interface{}, bool) {
+	if value == nil || index < 0 {
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	if index >= rv.Len() {
+		return nil, false
+	}
+	return rv.Index(index).Interface(), true
+}
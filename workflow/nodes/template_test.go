@@ -0,0 +1,309 @@
+package nodes
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjun/go-workflow-engine/workflow"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestResolverPreparesCallPlaceholders(t *testing.T) {
+	resolver, err := NewResolver(map[string]interface{}{
+		"document": map[string]interface{}{
+			"expiresAt": `{{addDuration(start, "7d")}}`,
+			"note":      `Ends {{ addDuration(start, "7d") }}, \{{literal}}`,
+			"tags":      []interface{}{"{{upper(name)}}", "{{name}}"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolver.expressions) != 2 {
+		t.Errorf("prepared %d expressions, want 2", len(resolver.expressions))
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	got, err := resolver.ResolveString(`{{addDuration(start, "7d")}}`, map[string]interface{}{"start": start})
+	if err != nil || got != start.AddDate(0, 0, 7) {
+		t.Errorf("ResolveString() = %v, %v", got, err)
+	}
+}
+
+func TestCallPlaceholderSyntaxErrorsFailTheBuild(t *testing.T) {
+	tests := []struct {
+		nodeType string
+		config   map[string]interface{}
+	}{
+		{"mongodb_insert", map[string]interface{}{
+			"database":   "app",
+			"collection": "users",
+			"document":   map[string]interface{}{"expiresAt": `{{addDuration(now, "7d"}}`},
+		}},
+		{"condition", map[string]interface{}{"lhs": "{{lower(name}}", "operator": "==", "rhs": "ada"}},
+		{"decision_table", map[string]interface{}{
+			"inputs":  []interface{}{"age"},
+			"outputs": []interface{}{"tier"},
+			"rules": []interface{}{
+				map[string]interface{}{"when": map[string]interface{}{"age": "-"}, "then": map[string]interface{}{"tier": "{{upper(}}"}},
+			},
+		}},
+		{"ruleset", map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"when": "true",
+					"then": []interface{}{map[string]interface{}{"set": "label", "value": "{{concat(name,)}}"}},
+				},
+			},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nodeType, func(t *testing.T) {
+			_, err := CreateNode(workflow.NodeDefinition{ID: "node", Type: tt.nodeType, Config: tt.config})
+			if err == nil || !strings.Contains(err.Error(), "parse error") {
+				t.Errorf("CreateNode() error = %v, want parse error", err)
+			}
+		})
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+		err  string
+	}{
+		{path: "user", want: []string{"user"}},
+		{path: "user.address.city", want: []string{"user", "address", "city"}},
+		{path: "users[0].email", want: []string{"users", "0", "email"}},
+		{path: "users.0.email", want: []string{"users", "0", "email"}},
+		{path: "matrix[1][2]", want: []string{"matrix", "1", "2"}},
+		{path: "users[-1]", want: []string{"users", "-1"}},
+		{path: "users[0", err: "unclosed [ in path users[0"},
+		{path: "users[first]", err: "invalid index [first] in path users[first]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := splitPath(tt.path)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("splitPath() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitPath() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestLookupVariable checks the paths lookupVariable, through walkPath, can
+// follow in JSON values and in the BSON types MongoDB results decode to.
+func TestLookupVariable(t *testing.T) {
+	ctx := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":    "Ada",
+			"tags":    []interface{}{"admin", "beta"},
+			"address": map[string]interface{}{"city": "London"},
+		},
+		"order.id": "o-1",
+		"nodes": map[string]interface{}{
+			"find.users": map[string]interface{}{"count": 2.0},
+		},
+		"matrix": []interface{}{[]interface{}{1.0, 2.0}, []interface{}{3.0, 4.0}},
+		"doc": primitive.D{
+			{Key: "name", Value: "Lin"},
+			{Key: "items", Value: primitive.A{primitive.M{"sku": "A-1"}, primitive.D{{Key: "sku", Value: "B-2"}}}},
+		},
+		"meta":  primitive.M{"source": "import"},
+		"count": 3.0,
+	}
+
+	tests := []struct {
+		path   string
+		want   interface{}
+		exists bool
+	}{
+		{"user.name", "Ada", true},
+		{"user.address.city", "London", true},
+		{"user.tags[1]", "beta", true},
+		{"user.tags.1", "beta", true},
+		{"matrix[1][0]", 3.0, true},
+		{"matrix.0.1", 2.0, true},
+		{"order.id", "o-1", true},
+		{"nodes.find.users.count", 2.0, true},
+		{"doc.name", "Lin", true},
+		{"doc.items[0].sku", "A-1", true},
+		{"doc.items.1.sku", "B-2", true},
+		{"meta.source", "import", true},
+		{"user.age", nil, false},
+		{"user.tags[2]", nil, false},
+		{"user.tags[-1]", nil, false},
+		{"user.tags.first", nil, false},
+		{"user.tags[first]", nil, false},
+		{"count.value", nil, false},
+		{"user.name.first", nil, false},
+		{"doc.items[5].sku", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, exists := lookupVariable(tt.path, ctx)
+			if exists != tt.exists || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookupVariable() = %v, %v, want %v, %v", got, exists, tt.want, tt.exists)
+			}
+		})
+	}
+}
+
+func TestResolveString(t *testing.T) {
+	ctx := map[string]interface{}{
+		"name":  "John",
+		"age":   25.0,
+		"user":  map[string]interface{}{"tier": "gold"},
+		"empty": nil,
+		"dir":   "tmp",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     interface{}
+	}{
+		{"plain string", "active", "active"},
+		{"whole placeholder keeps the type", "{{age}}", 25.0},
+		{"whole placeholder with spaces", "{{ name }}", "John"},
+		{"mixed text", "Hello {{name}}, you are {{age}}", "Hello John, you are 25"},
+		{"adjacent placeholders", "{{name}}{{age}}", "John25"},
+		{"object rendered as JSON", "user: {{user}}", `user: {"tier":"gold"}`},
+		{"null rendered as nothing", "[{{empty}}]", "[]"},
+		{"escaped braces", `Use \{{name\}} in templates`, "Use {{name}} in templates"},
+		{"escaped opening braces only", `\{{name}} is {{name}}`, "{{name}} is John"},
+		{"escaped closing braces", `{{name}} \}}`, "John }}"},
+		{"unclosed placeholder is kept", "Hello {{name", "Hello {{name"},
+		{"unclosed after a placeholder", "{{name}} and {{age", "John and {{age"},
+		// A backslash can't be escaped, so it always escapes the braces after it
+		{"backslash before a placeholder", `C:\{{dir}}`, "C:{{dir}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolver{}.ResolveString(tt.template, ctx)
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveString(%q) = %#v, %v, want %#v", tt.template, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveStringMissingVariables(t *testing.T) {
+	tests := []struct {
+		template string
+		want     map[MissingVariablePolicy]interface{} // nil for error
+	}{
+		{"{{nope}}", map[MissingVariablePolicy]interface{}{MissingEmpty: "", MissingKeep: "{{nope}}"}},
+		{"Hi {{nope}}!", map[MissingVariablePolicy]interface{}{MissingEmpty: "Hi !", MissingKeep: "Hi {{nope}}!"}},
+		{"{{ user.nope }}", map[MissingVariablePolicy]interface{}{MissingEmpty: "", MissingKeep: "{{ user.nope }}"}},
+		{"{{name}} {{nope}}", map[MissingVariablePolicy]interface{}{MissingEmpty: "John ", MissingKeep: "John {{nope}}"}},
+	}
+	ctx := map[string]interface{}{"name": "John", "user": map[string]interface{}{}}
+
+	for _, tt := range tests {
+		for _, policy := range []MissingVariablePolicy{MissingError, MissingEmpty, MissingKeep} {
+			t.Run(tt.template+"/"+string(policy), func(t *testing.T) {
+				got, err := Resolver{Missing: policy}.ResolveString(tt.template, ctx)
+				want, resolves := tt.want[policy]
+				if !resolves {
+					var missing *MissingVariableError
+					if !errors.As(err, &missing) {
+						t.Errorf("ResolveString() = %#v, %v, want a missing variable error", got, err)
+					}
+					return
+				}
+				if err != nil || got != want {
+					t.Errorf("ResolveString() = %#v, %v, want %#v", got, err, want)
+				}
+			})
+		}
+	}
+
+	// The zero Resolver fails like the error policy
+	if _, err := (Resolver{}).ResolveString("{{nope}}", ctx); err == nil || err.Error() != "variable nope not found in context" {
+		t.Errorf("ResolveString() error = %v", err)
+	}
+}
+
+func TestResolveArrays(t *testing.T) {
+	ctx := map[string]interface{}{
+		"tag1":   "go",
+		"tag2":   "mongo",
+		"list":   []interface{}{1.0, 2.0},
+		"age":    30.0,
+		"status": "active",
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{
+			name:  "templates inside an array",
+			value: []interface{}{"{{tag1}}", "tag: {{tag2}}", 3.0, true, nil},
+			want:  []interface{}{"go", "tag: mongo", 3.0, true, nil},
+		},
+		{
+			name:  "array under an operator",
+			value: map[string]interface{}{"tags": map[string]interface{}{"$in": []interface{}{"{{tag1}}", "{{tag2}}"}}},
+			want:  map[string]interface{}{"tags": map[string]interface{}{"$in": []interface{}{"go", "mongo"}}},
+		},
+		{
+			name: "array of maps",
+			value: map[string]interface{}{"$and": []interface{}{
+				map[string]interface{}{"age": map[string]interface{}{"$gte": "{{age}}"}},
+				map[string]interface{}{"status": "{{status}}"},
+			}},
+			want: map[string]interface{}{"$and": []interface{}{
+				map[string]interface{}{"age": map[string]interface{}{"$gte": 30.0}},
+				map[string]interface{}{"status": "active"},
+			}},
+		},
+		{
+			name:  "whole-value template keeps its type",
+			value: []interface{}{"{{list}}", "{{age}}"},
+			want:  []interface{}{[]interface{}{1.0, 2.0}, 30.0},
+		},
+		{
+			name:  "nested arrays",
+			value: []interface{}{[]interface{}{"{{tag1}}"}, []interface{}{}},
+			want:  []interface{}{[]interface{}{"go"}, []interface{}{}},
+		},
+		{
+			name:  "BSON array",
+			value: primitive.A{"{{tag1}}", 1.0},
+			want:  []interface{}{"go", 1.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolver{}.Resolve(tt.value, ctx)
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %#v, %v, want %#v", got, err, tt.want)
+			}
+		})
+	}
+
+	// A missing variable anywhere in the array fails the whole array
+	_, err := Resolver{}.Resolve([]interface{}{"{{tag1}}", map[string]interface{}{"x": "{{nope}}"}}, ctx)
+	var missing *MissingVariableError
+	if !errors.As(err, &missing) || missing.Name != "nope" {
+		t.Errorf("Resolve() error = %v, want nope not found", err)
+	}
+}
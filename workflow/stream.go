@@ -0,0 +1,86 @@
+package workflow
+
+import (
+	"context"
+	"log"
+)
+
+// runStream calls a Streamer and runs the nodes on its "item" edges once for
+// every item it emits. Each item runs in its own scope isolated from ctx,
+// with the item's data set on it, and its own join and loop bookkeeping. The
+// scope is dropped when the item's sub-graph finishes, so items cannot see
+// or overwrite each other's writes or node records and nothing they write
+// reaches ctx.
+func (e *Engine) runStream(nodeId string, streamer Streamer, ctx *WorkflowContext) (NodeResult, error) {
+	itemNodes := e.findNextNodes(nodeId, StreamItemOutput)
+
+	emit := func(item map[string]interface{}) error {
+		if len(itemNodes) == 0 {
+			return nil
+		}
+
+		scope := ctx.Isolate()
+		for key, value := range item {
+			scope.Set(key, value)
+		}
+
+		return e.subRun().runSubGraph(nodeId, itemNodes, item, scope)
+	}
+
+	log.Printf("Streaming items from %s to %v", nodeId, itemNodes)
+	return streamer.Stream(ctx.GoContext(), nodeInput(ctx), emit)
+}
+
+// runScope calls a Scope and runs the nodes on its "body" edges when it asks
+// for them. Every call of body runs in a fresh scope isolated from ctx, so a
+// retried body starts over, and only the writes and node records of the last
+// call are merged into ctx, and only when the Scope keeps them.
+func (e *Engine) runScope(nodeId string, scope Scope, ctx *WorkflowContext) (NodeResult, error) {
+	bodyNodes := e.findNextNodes(nodeId, ScopeBodyOutput)
+
+	var bodyScope *WorkflowContext
+	body := func(goCtx context.Context) error {
+		bodyScope = ctx.IsolateWithContext(goCtx)
+		if len(bodyNodes) == 0 {
+			return nil
+		}
+		return e.subRun().runSubGraph(nodeId, bodyNodes, nil, bodyScope)
+	}
+
+	log.Printf("Running scope %s with body %v", nodeId, bodyNodes)
+	response, keep, err := scope.RunScope(ctx.GoContext(), nodeInput(ctx), body)
+	if err == nil && keep && bodyScope != nil {
+		ctx.mergeScope(bodyScope)
+		ctx.addNodeOutputs(bodyScope.NodeChanges())
+	}
+	return response, err
+}
+
+// runSubGraph runs the given nodes, entered from nodeId with data, in scope,
+// and fails like Execute if a join inside them never fired.
+func (e *Engine) runSubGraph(nodeId string, nodeIds []string, data map[string]interface{}, scope *WorkflowContext) error {
+	arrival := Branch{From: nodeId, Data: data}
+	var err error
+	if len(nodeIds) == 1 {
+		err = e.executeNode(nodeIds[0], arrival, scope)
+	} else {
+		err = e.executeNodeParallel(nodeIds, arrival, scope)
+	}
+	if err != nil {
+		return err
+	}
+	return e.pendingJoinsError()
+}
+
+// subRun returns an engine for running a sub-graph such as a streamed item or
+// a scope body. It shares the workflow and built nodes but starts with fresh
+// join and loop state, so a join inside the sub-graph fires once per run.
+func (e *Engine) subRun() *Engine {
+	return &Engine{
+		Workflow:   e.Workflow,
+		Nodes:      e.Nodes,
+		Context:    e.Context,
+		loopCounts: make(map[Edge]int),
+		joins:      make(map[string]*joinState),
+	}
+}
@@ -0,0 +1,137 @@
+package workflow
+
+import "fmt"
+
+// subGraphOutput returns the output whose edges lead into a sub-graph the
+// node runs itself: the items of a Streamer or the body of a Scope.
+func subGraphOutput(node Node) (string, bool) {
+	switch node.(type) {
+	case Streamer:
+		return StreamItemOutput, true
+	case Scope:
+		return ScopeBodyOutput, true
+	}
+	return "", false
+}
+
+// findSubGraphExits reports edges that lead from a sub-graph back into the
+// graph around it. The nodes behind such an edge would run inside the
+// sub-graph's scope, once per item or inside a transaction, as well as in
+// the workflow itself. The graph around a sub-graph is everything reachable
+// from the start node without entering that sub-graph.
+func (e *Engine) findSubGraphExits(startNode string, order []string, nodes map[string]Node) ValidationErrors {
+	var problems ValidationErrors
+	for _, id := range order {
+		output, ok := subGraphOutput(nodes[id])
+		if !ok {
+			continue
+		}
+		entry := e.findNextNodes(id, output)
+		if len(entry) == 0 {
+			continue
+		}
+
+		isEntry := func(edge Edge) bool {
+			return edge.From == id && edge.Output == output
+		}
+		inside := e.reachableVia(entry, nil)
+		outside := e.reachableVia([]string{startNode}, isEntry)
+
+		for i := range e.Workflow.Edges {
+			edge := e.Workflow.Edges[i]
+			fromInside := isEntry(edge) || (inside[edge.From] && !outside[edge.From])
+			if !fromInside || !outside[edge.To] {
+				continue
+			}
+			problems = append(problems, ValidationError{
+				NodeID:  edge.From,
+				Edge:    &edge,
+				Code:    "subgraph_exit",
+				Message: fmt.Sprintf("edge leads out of the %q sub-graph of %s into the rest of the workflow", output, id),
+			})
+		}
+	}
+	return problems
+}
+
+// findConcurrentScopeBodies reports everything that would run nodes of a
+// Scope's body at the same time: outputs with several edges, which run in
+// parallel, streamers, which keep reading their source while their items run,
+// and nodes that declare a concurrency above 1. The body's Go context, such as
+// a MongoDB session, may not be used concurrently.
+func (e *Engine) findConcurrentScopeBodies(order []string, nodes map[string]Node) ValidationErrors {
+	var problems ValidationErrors
+	for _, id := range order {
+		if _, ok := nodes[id].(Scope); !ok {
+			continue
+		}
+		entry := e.findNextNodes(id, ScopeBodyOutput)
+		if len(entry) == 0 {
+			continue
+		}
+		if len(entry) > 1 {
+			problems = append(problems, ValidationError{
+				NodeID:  id,
+				Code:    "parallel_in_scope",
+				Message: fmt.Sprintf("%q output has %d edges, the body must run one node at a time", ScopeBodyOutput, len(entry)),
+			})
+		}
+
+		body := e.reachableVia(entry, nil)
+		for _, bodyId := range order {
+			if !body[bodyId] {
+				continue
+			}
+			reported := make(map[string]bool)
+			for _, edge := range e.Workflow.Edges {
+				if edge.From != bodyId || reported[edge.Output] {
+					continue
+				}
+				if next := e.findNextNodes(bodyId, edge.Output); len(next) > 1 {
+					reported[edge.Output] = true
+					problems = append(problems, ValidationError{
+						NodeID:  bodyId,
+						Code:    "parallel_in_scope",
+						Message: fmt.Sprintf("output %q runs %d nodes in parallel inside the body of %s, which must run one node at a time", edge.Output, len(next), id),
+					})
+				}
+			}
+			if _, ok := nodes[bodyId].(Streamer); ok {
+				problems = append(problems, ValidationError{
+					NodeID:  bodyId,
+					Code:    "parallel_in_scope",
+					Message: fmt.Sprintf("streams are not allowed inside the body of %s, they read the next item while earlier items run", id),
+				})
+			} else if declarer, ok := nodes[bodyId].(ConcurrencyDeclarer); ok && declarer.MaxConcurrency() > 1 {
+				problems = append(problems, ValidationError{
+					NodeID:  bodyId,
+					Code:    "parallel_in_scope",
+					Message: fmt.Sprintf("concurrency %d is not allowed inside the body of %s, which must run one node at a time", declarer.MaxConcurrency(), id),
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// reachableVia returns the nodes reachable from the given nodes, including
+// them, following every edge that skip does not reject.
+func (e *Engine) reachableVia(from []string, skip func(Edge) bool) map[string]bool {
+	reachable := make(map[string]bool, len(from))
+	queue := append([]string(nil), from...)
+	for _, id := range from {
+		reachable[id] = true
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range e.Workflow.Edges {
+			if edge.From != current || reachable[edge.To] || (skip != nil && skip(edge)) {
+				continue
+			}
+			reachable[edge.To] = true
+			queue = append(queue, edge.To)
+		}
+	}
+	return reachable
+}
@@ -1,9 +1,83 @@
 package workflow
 
+import "context"
+
 type Node interface {
 	Execute(ctx map[string]interface{}) (NodeResult, error)
 }
 
+// ContextNode is implemented by nodes that can run under a Go context. The
+// engine calls ExecuteContext instead of Execute, passing the context of the
+// scope the node runs in, such as the session of an enclosing transaction.
+type ContextNode interface {
+	ExecuteContext(goCtx context.Context, ctx map[string]interface{}) (NodeResult, error)
+}
+
+// OutputDeclarer is implemented by nodes that know every output label they can emit.
+// Engine.Validate uses it to reject edges on outputs that can never fire.
+type OutputDeclarer interface {
+	Outputs() []string
+}
+
+// Joiner is implemented by nodes that act as a barrier for the branches
+// arriving on their incoming edges. Instead of calling Execute for every
+// arrival, the engine collects the branches and calls Join exactly once, as
+// soon as Ready reports that enough of them have arrived.
+type Joiner interface {
+	Ready(arrived, expected int) bool
+	Join(ctx map[string]interface{}, branches []Branch) (NodeResult, error)
+}
+
+// StreamItemOutput is the output label whose edges a Streamer runs once per item.
+const StreamItemOutput = "item"
+
+// Streamer is implemented by nodes that produce a stream of items, such as
+// documents read from a cursor. Instead of calling Execute, the engine calls
+// Stream with an emit function that runs the nodes on the streamer's "item"
+// edges for one item and returns when they have finished, so a streamer never
+// has more items in flight than it has concurrent emit calls. Emit may be
+// called from several goroutines at once. When Stream returns, execution
+// continues on the returned result's output like for any other node.
+type Streamer interface {
+	Stream(goCtx context.Context, ctx map[string]interface{}, emit func(item map[string]interface{}) error) (NodeResult, error)
+}
+
+// ConcurrencyDeclarer is implemented by nodes that run their own work
+// concurrently, such as a Streamer with several workers. Engine.Validate uses
+// it to keep concurrent nodes out of a Scope's body.
+type ConcurrencyDeclarer interface {
+	MaxConcurrency() int
+}
+
+// ScopeBodyOutput is the output label whose edges a Scope runs as its body.
+const ScopeBodyOutput = "body"
+
+// Scope is implemented by nodes that run the sub-graph on their "body" edges
+// inside something they set up and tear down, such as a database transaction.
+// RunScope calls body, possibly more than once if it retries, with the Go
+// context the body's nodes should run under. A retry re-runs every node in
+// the body, including ones the scope doesn't cover, such as a node calling
+// an external service, so their side effects may happen more than once.
+// The body's writes and node records go to a scope of their own and are only
+// merged into the context when keep is true; the engine then continues on
+// the result's output like for any other node. The body runs one node at a
+// time, since its Go context may not be safe for concurrent use:
+// Engine.Validate rejects parallel edges and concurrent nodes in it.
+type Scope interface {
+	RunScope(goCtx context.Context, ctx map[string]interface{}, body func(goCtx context.Context) error) (result NodeResult, keep bool, err error)
+}
+
+// Branch is one incoming branch of a join: the node it arrived from, the
+// data the branch wrote to its own scope (or, for a branch that was never
+// forked, the data its last node produced) and the keys it deleted there.
+type Branch struct {
+	From    string
+	Data    map[string]interface{}
+	Deleted map[string]bool
+
+	scope *WorkflowContext
+}
+
 type NodeDefinition struct {
 	ID     string                 `json:"id"`
 	Type   string                 `json:"type"`
@@ -13,6 +87,8 @@ type NodeDefinition struct {
 type NodeResult struct {
 	Output string
 	Data   map[string]interface{}
+	// Deleted lists keys to remove from the context after Data is written
+	Deleted []string
 }
 
 type Workflow struct {
@@ -26,4 +102,8 @@ type Edge struct {
 	From   string `json:"from"`
 	To     string `json:"to"`
 	Output string `json:"output"`
+	// MaxIterations marks the edge as a deliberate loop back-edge that may be
+	// followed at most this many times per execution. Zero means the edge is
+	// not allowed to close a cycle.
+	MaxIterations int `json:"maxIterations,omitempty"`
 }
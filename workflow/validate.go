@@ -0,0 +1,218 @@
+package workflow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ValidationError describes a single problem found in a workflow definition.
+type ValidationError struct {
+	NodeID  string `json:"nodeId,omitempty"`
+	Edge    *Edge  `json:"edge,omitempty"`
+	Edges   []Edge `json:"edges,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (v ValidationError) Error() string {
+	if v.NodeID != "" {
+		return fmt.Sprintf("node %s: %s", v.NodeID, v.Message)
+	}
+	return v.Message
+}
+
+// ValidationErrors is the list of every problem found by Engine.Validate.
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, err := range v {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("workflow is invalid: %s", strings.Join(messages, "; "))
+}
+
+// Validate checks the workflow graph before execution: node IDs must be
+// unique, there must be exactly one start node, every edge must connect
+// existing nodes on an output the source node can emit, every node must
+// be reachable from the start node, every join must be able to fire and sit
+// outside any loop, every cycle must be broken by an edge with MaxIterations
+// set, no edge may lead out of a sub-graph such as a stream's items and
+// nothing may run in parallel inside a scope's body. Nodes that have not
+// been built yet are created through NodeFactory so configuration errors
+// are reported too.
+// It returns nil when the workflow is valid.
+func (e *Engine) Validate() ValidationErrors {
+	var problems ValidationErrors
+
+	defs := make(map[string]NodeDefinition)
+	var order []string
+	var startNodes []string
+	for _, nodeDef := range e.Workflow.Nodes {
+		if nodeDef.ID == "" {
+			problems = append(problems, ValidationError{
+				Code:    "missing_id",
+				Message: fmt.Sprintf("node of type %q has no id", nodeDef.Type),
+			})
+			continue
+		}
+		if _, exists := defs[nodeDef.ID]; exists {
+			problems = append(problems, ValidationError{
+				NodeID:  nodeDef.ID,
+				Code:    "duplicate_node",
+				Message: "node id is used more than once",
+			})
+			continue
+		}
+		defs[nodeDef.ID] = nodeDef
+		order = append(order, nodeDef.ID)
+		if nodeDef.Type == "start" {
+			startNodes = append(startNodes, nodeDef.ID)
+		}
+	}
+
+	switch len(startNodes) {
+	case 0:
+		problems = append(problems, ValidationError{
+			Code:    "no_start_node",
+			Message: "workflow has no start node",
+		})
+	case 1:
+	default:
+		for _, id := range startNodes[1:] {
+			problems = append(problems, ValidationError{
+				NodeID:  id,
+				Code:    "multiple_start_nodes",
+				Message: fmt.Sprintf("workflow already has start node %s", startNodes[0]),
+			})
+		}
+	}
+
+	outputs := make(map[string][]string)
+	nodes := make(map[string]Node)
+	for _, id := range order {
+		nodeDef := defs[id]
+		node, built := e.Nodes[nodeDef.ID]
+		if !built {
+			if NodeFactory == nil {
+				continue
+			}
+			var err error
+			node, err = NodeFactory(nodeDef)
+			if err != nil {
+				problems = append(problems, ValidationError{
+					NodeID:  nodeDef.ID,
+					Code:    "invalid_config",
+					Message: err.Error(),
+				})
+				continue
+			}
+		}
+		nodes[nodeDef.ID] = node
+		if declarer, ok := node.(OutputDeclarer); ok {
+			outputs[nodeDef.ID] = declarer.Outputs()
+		}
+		// A join that is not ready once every incoming branch has arrived never fires
+		if joiner, ok := node.(Joiner); ok {
+			sources := len(e.incomingSources(nodeDef.ID))
+			if !joiner.Ready(sources, sources) {
+				problems = append(problems, ValidationError{
+					NodeID:  nodeDef.ID,
+					Code:    "join_never_ready",
+					Message: fmt.Sprintf("join can never fire with the %d nodes that have edges into it", sources),
+				})
+			}
+		}
+	}
+
+	for i := range e.Workflow.Edges {
+		edge := e.Workflow.Edges[i]
+		if _, ok := defs[edge.From]; !ok {
+			problems = append(problems, ValidationError{
+				NodeID:  edge.From,
+				Edge:    &edge,
+				Code:    "unknown_source",
+				Message: fmt.Sprintf("edge source %q does not exist", edge.From),
+			})
+		}
+		if _, ok := defs[edge.To]; !ok {
+			problems = append(problems, ValidationError{
+				NodeID:  edge.To,
+				Edge:    &edge,
+				Code:    "unknown_target",
+				Message: fmt.Sprintf("edge target %q does not exist", edge.To),
+			})
+		}
+		if allowed, ok := outputs[edge.From]; ok && !slices.Contains(allowed, edge.Output) {
+			message := fmt.Sprintf("node type %s cannot emit output %q (expected one of: %s)",
+				defs[edge.From].Type, edge.Output, strings.Join(allowed, ", "))
+			problems = append(problems, ValidationError{
+				NodeID:  edge.From,
+				Edge:    &edge,
+				Code:    "unknown_output",
+				Message: message,
+			})
+		}
+		if edge.MaxIterations < 0 {
+			problems = append(problems, ValidationError{
+				NodeID:  edge.From,
+				Edge:    &edge,
+				Code:    "invalid_max_iterations",
+				Message: "maxIterations cannot be negative",
+			})
+		}
+		if defs[edge.To].Type == "start" {
+			problems = append(problems, ValidationError{
+				NodeID:  edge.To,
+				Edge:    &edge,
+				Code:    "edge_into_start",
+				Message: "start node cannot have incoming edges",
+			})
+		}
+	}
+
+	if len(startNodes) > 0 {
+		reachable := e.reachableFrom(startNodes[0])
+		for _, id := range order {
+			if defs[id].Type == "start" || reachable[id] {
+				continue
+			}
+			problems = append(problems, ValidationError{
+				NodeID:  id,
+				Code:    "unreachable_node",
+				Message: fmt.Sprintf("node cannot be reached from start node %s", startNodes[0]),
+			})
+		}
+	}
+
+	problems = append(problems, e.findCycles(order)...)
+	problems = append(problems, e.findJoinsInLoops(order, nodes)...)
+	if len(startNodes) > 0 {
+		problems = append(problems, e.findExclusiveJoins(startNodes[0], order, nodes)...)
+		problems = append(problems, e.findSubGraphExits(startNodes[0], order, nodes)...)
+	}
+	problems = append(problems, e.findConcurrentScopeBodies(order, nodes)...)
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// reachableFrom returns the set of node IDs reachable from the given node by following edges.
+func (e *Engine) reachableFrom(nodeId string) map[string]bool {
+	reachable := map[string]bool{nodeId: true}
+	queue := []string{nodeId}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range e.Workflow.Edges {
+			if edge.From == current && !reachable[edge.To] {
+				reachable[edge.To] = true
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+	return reachable
+}
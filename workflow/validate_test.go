@@ -0,0 +1,351 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// funcNode is a Node that runs the given function.
+type funcNode func(ctx map[string]interface{}) (NodeResult, error)
+
+func (f funcNode) Execute(ctx map[string]interface{}) (NodeResult, error) {
+	return f(ctx)
+}
+
+// emit returns a node that always emits output with data.
+func emit(output string, data map[string]interface{}) funcNode {
+	return func(map[string]interface{}) (NodeResult, error) {
+		return NodeResult{Output: output, Data: data}, nil
+	}
+}
+
+// declaringNode is a node that declares the outputs it can emit.
+type declaringNode struct {
+	Node
+	outputs []string
+}
+
+func (n declaringNode) Outputs() []string {
+	return n.outputs
+}
+
+// concurrentNode is a node that declares it runs several workers.
+type concurrentNode struct {
+	Node
+	workers int
+}
+
+func (n concurrentNode) MaxConcurrency() int {
+	return n.workers
+}
+
+// stubJoin is a Joiner that fires once count branches arrived, or all of
+// them when count is 0, and merges them with merge.
+type stubJoin struct {
+	count int
+	merge MergeStrategy
+}
+
+func (j stubJoin) Ready(arrived, expected int) bool {
+	if j.count == 0 {
+		return arrived >= expected
+	}
+	return arrived >= j.count
+}
+
+func (j stubJoin) Join(ctx map[string]interface{}, branches []Branch) (NodeResult, error) {
+	merged, deleted, err := MergeBranches(branches, j.merge)
+	return NodeResult{Output: "default", Data: merged, Deleted: deleted}, err
+}
+
+func (j stubJoin) Execute(ctx map[string]interface{}) (NodeResult, error) {
+	return j.Join(ctx, nil)
+}
+
+// stubStream is a Streamer that emits its items one after the other.
+type stubStream struct {
+	items []map[string]interface{}
+}
+
+func (s stubStream) Stream(goCtx context.Context, ctx map[string]interface{}, emit func(item map[string]interface{}) error) (NodeResult, error) {
+	for _, item := range s.items {
+		if err := emit(item); err != nil {
+			return NodeResult{}, err
+		}
+	}
+	return NodeResult{Output: "default"}, nil
+}
+
+func (s stubStream) Execute(ctx map[string]interface{}) (NodeResult, error) {
+	return NodeResult{Output: "default"}, nil
+}
+
+// stubScope is a Scope that runs its body once and keeps it unless it failed.
+type stubScope struct{}
+
+func (stubScope) RunScope(goCtx context.Context, ctx map[string]interface{}, body func(goCtx context.Context) error) (NodeResult, bool, error) {
+	if err := body(goCtx); err != nil {
+		return NodeResult{}, false, err
+	}
+	return NodeResult{Output: "default"}, true, nil
+}
+
+func (stubScope) Execute(ctx map[string]interface{}) (NodeResult, error) {
+	return NodeResult{Output: "default"}, nil
+}
+
+// testNode is a node of a workflow built by newTestEngine. A nil node is
+// left to NodeFactory.
+type testNode struct {
+	id       string
+	nodeType string
+	node     Node
+}
+
+func start() testNode {
+	return testNode{"start", "start", emit("default", nil)}
+}
+
+func step(id string, node Node) testNode {
+	return testNode{id, "step", node}
+}
+
+func newTestEngine(nodes []testNode, edges ...Edge) *Engine {
+	engine := NewEngine()
+	for _, n := range nodes {
+		engine.Workflow.Nodes = append(engine.Workflow.Nodes, NodeDefinition{ID: n.id, Type: n.nodeType})
+		if n.node != nil {
+			engine.Nodes[n.id] = n.node
+		}
+	}
+	engine.Workflow.Edges = edges
+	return engine
+}
+
+// problemCodes lists the problems as code@nodeId, in the order they were reported.
+func problemCodes(problems ValidationErrors) []string {
+	var codes []string
+	for _, problem := range problems {
+		codes = append(codes, fmt.Sprintf("%s@%s", problem.Code, problem.NodeID))
+	}
+	return codes
+}
+
+func TestValidate(t *testing.T) {
+	previous := NodeFactory
+	NodeFactory = func(def NodeDefinition) (Node, error) {
+		return nil, fmt.Errorf("%s nodes need a collection", def.Type)
+	}
+	t.Cleanup(func() { NodeFactory = previous })
+
+	condition := declaringNode{emit("true", nil), []string{"true", "false"}}
+
+	tests := []struct {
+		name  string
+		nodes []testNode
+		edges []Edge
+		want  []string
+	}{
+		{
+			name:  "valid",
+			nodes: []testNode{start(), step("a", emit("default", nil))},
+			edges: []Edge{{From: "start", To: "a", Output: "default"}},
+		},
+		{
+			name:  "node without id",
+			nodes: []testNode{start(), step("", emit("default", nil))},
+			want:  []string{"missing_id@"},
+		},
+		{
+			name:  "duplicate node id",
+			nodes: []testNode{start(), step("a", emit("default", nil)), step("a", emit("default", nil))},
+			edges: []Edge{{From: "start", To: "a", Output: "default"}},
+			want:  []string{"duplicate_node@a"},
+		},
+		{
+			name:  "no start node",
+			nodes: []testNode{step("a", emit("default", nil))},
+			want:  []string{"no_start_node@"},
+		},
+		{
+			name:  "two start nodes",
+			nodes: []testNode{start(), {"second", "start", emit("default", nil)}},
+			want:  []string{"multiple_start_nodes@second"},
+		},
+		{
+			name:  "node config rejected by the factory",
+			nodes: []testNode{start(), {"insert", "mongodb_insert", nil}},
+			edges: []Edge{{From: "start", To: "insert", Output: "default"}},
+			want:  []string{"invalid_config@insert"},
+		},
+		{
+			name:  "join waiting for more branches than it has",
+			nodes: []testNode{start(), step("a", emit("default", nil)), step("b", emit("default", nil)), step("join", stubJoin{count: 3})},
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "start", To: "b", Output: "default"},
+				{From: "a", To: "join", Output: "default"},
+				{From: "b", To: "join", Output: "default"},
+			},
+			want: []string{"join_never_ready@join"},
+		},
+		{
+			name:  "join inside a guarded loop",
+			nodes: []testNode{start(), step("a", emit("default", nil)), step("join", stubJoin{}), step("retry", condition)},
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "a", To: "join", Output: "default"},
+				{From: "join", To: "retry", Output: "default"},
+				{From: "retry", To: "a", Output: "true", MaxIterations: 3},
+			},
+			want: []string{"join_in_loop@join"},
+		},
+		{
+			name:  "join waiting for both outputs of a condition",
+			nodes: []testNode{start(), step("check", condition), step("yes", emit("default", nil)), step("no", emit("default", nil)), step("join", stubJoin{})},
+			edges: []Edge{
+				{From: "start", To: "check", Output: "default"},
+				{From: "check", To: "yes", Output: "true"},
+				{From: "check", To: "no", Output: "false"},
+				{From: "yes", To: "join", Output: "default"},
+				{From: "no", To: "join", Output: "default"},
+			},
+			want: []string{"join_exclusive_branches@join"},
+		},
+		{
+			name:  "join of any output of a condition",
+			nodes: []testNode{start(), step("check", condition), step("yes", emit("default", nil)), step("no", emit("default", nil)), step("join", stubJoin{count: 1})},
+			edges: []Edge{
+				{From: "start", To: "check", Output: "default"},
+				{From: "check", To: "yes", Output: "true"},
+				{From: "check", To: "no", Output: "false"},
+				{From: "yes", To: "join", Output: "default"},
+				{From: "no", To: "join", Output: "default"},
+			},
+		},
+		{
+			name:  "join of parallel branches after a condition",
+			nodes: []testNode{start(), step("check", condition), step("a", emit("default", nil)), step("b", emit("default", nil)), step("join", stubJoin{})},
+			edges: []Edge{
+				{From: "start", To: "check", Output: "default"},
+				{From: "check", To: "a", Output: "true"},
+				{From: "check", To: "b", Output: "true"},
+				{From: "a", To: "join", Output: "default"},
+				{From: "b", To: "join", Output: "default"},
+			},
+		},
+		{
+			name:  "edge from unknown node",
+			nodes: []testNode{start(), step("a", emit("default", nil))},
+			edges: []Edge{{From: "start", To: "a", Output: "default"}, {From: "ghost", To: "a", Output: "default"}},
+			want:  []string{"unknown_source@ghost"},
+		},
+		{
+			name:  "edge to unknown node",
+			nodes: []testNode{start()},
+			edges: []Edge{{From: "start", To: "ghost", Output: "default"}},
+			want:  []string{"unknown_target@ghost"},
+		},
+		{
+			name:  "output the node cannot emit",
+			nodes: []testNode{start(), step("check", condition), step("a", emit("default", nil))},
+			edges: []Edge{{From: "start", To: "check", Output: "default"}, {From: "check", To: "a", Output: "maybe"}},
+			want:  []string{"unknown_output@check"},
+		},
+		{
+			name:  "negative maxIterations",
+			nodes: []testNode{start(), step("a", emit("default", nil))},
+			edges: []Edge{{From: "start", To: "a", Output: "default", MaxIterations: -1}},
+			want:  []string{"invalid_max_iterations@start"},
+		},
+		{
+			name:  "edge into the start node",
+			nodes: []testNode{start(), step("a", emit("default", nil))},
+			edges: []Edge{{From: "start", To: "a", Output: "default"}, {From: "a", To: "start", Output: "default", MaxIterations: 2}},
+			want:  []string{"edge_into_start@start"},
+		},
+		{
+			name:  "unreachable node",
+			nodes: []testNode{start(), step("a", emit("default", nil))},
+			want:  []string{"unreachable_node@a"},
+		},
+		{
+			name:  "unguarded cycle",
+			nodes: []testNode{start(), step("a", emit("default", nil)), step("b", emit("default", nil))},
+			edges: []Edge{
+				{From: "start", To: "a", Output: "default"},
+				{From: "a", To: "b", Output: "default"},
+				{From: "b", To: "a", Output: "default"},
+			},
+			want: []string{"cycle@a"},
+		},
+		{
+			name:  "edge out of a stream's items",
+			nodes: []testNode{start(), step("stream", stubStream{}), step("item", emit("default", nil)), step("after", emit("default", nil))},
+			edges: []Edge{
+				{From: "start", To: "stream", Output: "default"},
+				{From: "stream", To: "item", Output: StreamItemOutput},
+				{From: "stream", To: "after", Output: "default"},
+				{From: "item", To: "after", Output: "default"},
+			},
+			want: []string{"subgraph_exit@item"},
+		},
+		{
+			name:  "parallel edges into a scope's body",
+			nodes: []testNode{start(), step("tx", stubScope{}), step("a", emit("default", nil)), step("b", emit("default", nil))},
+			edges: []Edge{
+				{From: "start", To: "tx", Output: "default"},
+				{From: "tx", To: "a", Output: ScopeBodyOutput},
+				{From: "tx", To: "b", Output: ScopeBodyOutput},
+			},
+			want: []string{"parallel_in_scope@tx"},
+		},
+		{
+			name:  "concurrent node in a scope's body",
+			nodes: []testNode{start(), step("tx", stubScope{}), step("find", concurrentNode{emit("default", nil), 4})},
+			edges: []Edge{
+				{From: "start", To: "tx", Output: "default"},
+				{From: "tx", To: "find", Output: ScopeBodyOutput},
+			},
+			want: []string{"parallel_in_scope@find"},
+		},
+		{
+			name:  "stream in a scope's body",
+			nodes: []testNode{start(), step("tx", stubScope{}), step("find", stubStream{}), step("item", emit("default", nil))},
+			edges: []Edge{
+				{From: "start", To: "tx", Output: "default"},
+				{From: "tx", To: "find", Output: ScopeBodyOutput},
+				{From: "find", To: "item", Output: StreamItemOutput},
+			},
+			want: []string{"parallel_in_scope@find"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := newTestEngine(tt.nodes, tt.edges...).Validate()
+			if got := problemCodes(problems); !slices.Equal(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+			if len(tt.want) == 0 && problems != nil {
+				t.Errorf("Validate() = %#v, want nil", problems)
+			}
+		})
+	}
+}
+
+func TestExecuteRejectsInvalidWorkflow(t *testing.T) {
+	engine := newTestEngine([]testNode{start()}, Edge{From: "start", To: "ghost", Output: "default"})
+
+	err := engine.Execute(nil)
+	var problems ValidationErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("Execute() = %v, want ValidationErrors", err)
+	}
+	if got := problemCodes(problems); !slices.Equal(got, []string{"unknown_target@ghost"}) {
+		t.Errorf("Execute() problems = %v", got)
+	}
+}